@@ -33,6 +33,12 @@
 //	artifactvalidator -v -s artifacts/data/*.yaml
 //
 // The output is a list of potential issues in those files.
+//
+// Labels are checked against the labels of the forensic artifacts
+// repository. Custom labels can be added with a YAML file that lists their
+// name and description:
+//
+//	artifactvalidator -labels labels.yaml artifacts/data/*.yaml
 package main
 
 import (
@@ -55,6 +61,7 @@ func main() { // nolint:gocyclo,gocognit,funlen
 	exitcode := 0
 	// parse flags
 	var verbose, summary, quite, nofail bool
+	var labelsFile string
 	flag.BoolVar(&verbose, "verbose", false, "show common flaws as well")
 	flag.BoolVar(&verbose, "v", false, "show common flaws as well"+" (shorthand)")
 	flag.BoolVar(&quite, "quite", false, "hide informational flaws")
@@ -62,6 +69,7 @@ func main() { // nolint:gocyclo,gocognit,funlen
 	flag.BoolVar(&summary, "summary", false, "show summary")
 	flag.BoolVar(&summary, "s", false, "show summary"+" (shorthand)")
 	flag.BoolVar(&nofail, "no-fail", false, "do not fail on flaws")
+	flag.StringVar(&labelsFile, "labels", "", "YAML file with additional labels")
 	flag.Parse()
 
 	// setup logging
@@ -90,8 +98,19 @@ func main() { // nolint:gocyclo,gocognit,funlen
 		args = files
 	}
 
+	// load labels
+	labels := goartifacts.DefaultLabels()
+	if labelsFile != "" {
+		customLabels, err := goartifacts.LoadLabels(labelsFile)
+		if err != nil {
+			logger.Crit(err.Error())
+			os.Exit(1)
+		}
+		labels.Add(customLabels...)
+	}
+
 	// parse artifacts
-	flaws, err := ValidateFiles(args)
+	flaws, err := ValidateFiles(args, labels)
 	if err != nil {
 		logger.Crit(err.Error())
 		os.Exit(1)
@@ -139,13 +158,10 @@ func main() { // nolint:gocyclo,gocognit,funlen
 				for _, supportedOS := range artifactDefinition.SupportedOs {
 					inc(oss, supportedOS)
 				}
-				// for _, label := range artifactDefinition.Labels {
-				// 	inc(labels, label)
-				// }
 			}
 			printTable("Artifact definition by type", sourcetypes)
 			printTable("Artifact definition by OS", oss)
-			// printTable("Artifact definition by label", labels)
+			printTable("Artifact definition by label", goartifacts.CountLabels(artifactDefinitions))
 		}
 	}
 	os.Exit(exitcode)
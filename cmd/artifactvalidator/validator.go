@@ -62,11 +62,12 @@ type Flaw struct {
 
 // The validator performs all validations and stores the found flaws.
 type validator struct {
-	flaws []Flaw
+	flaws  []Flaw
+	labels goartifacts.LabelTaxonomy
 }
 
 func newValidator() *validator {
-	return &validator{[]Flaw{}}
+	return &validator{[]Flaw{}, goartifacts.DefaultLabels()}
 }
 
 func (r *validator) addFlawf(filename, artifactDefiniton string, severity Severity, format string, a ...interface{}) {
@@ -88,8 +89,9 @@ func (r *validator) addErrorf(filename, artifactDefiniton, format string, a ...i
 	r.addFlawf(filename, artifactDefiniton, Error, format, a...)
 }
 
-// ValidateFiles checks a list of files for various flaws.
-func ValidateFiles(filenames []string) (flaws []Flaw, err error) {
+// ValidateFiles checks a list of files for various flaws. Labels are checked
+// against the taxonomy, goartifacts.DefaultLabels if nil.
+func ValidateFiles(filenames []string, labels goartifacts.LabelTaxonomy) (flaws []Flaw, err error) {
 	artifactDefinitionMap := map[string][]goartifacts.ArtifactDefinition{}
 
 	// decode file
@@ -105,13 +107,17 @@ func ValidateFiles(filenames []string) (flaws []Flaw, err error) {
 	}
 
 	// validate
-	flaws = append(flaws, ValidateArtifactDefinitions(artifactDefinitionMap)...)
+	flaws = append(flaws, ValidateArtifactDefinitions(artifactDefinitionMap, labels)...)
 	return
 }
 
 // ValidateArtifactDefinitions validates a map of artifact definitions and returns any flaws found in those.
-func ValidateArtifactDefinitions(artifactDefinitionMap map[string][]goartifacts.ArtifactDefinition) []Flaw {
+// Labels are checked against the taxonomy, goartifacts.DefaultLabels if nil.
+func ValidateArtifactDefinitions(artifactDefinitionMap map[string][]goartifacts.ArtifactDefinition, labels goartifacts.LabelTaxonomy) []Flaw {
 	r := newValidator()
+	if labels != nil {
+		r.labels = labels
+	}
 	r.validateArtifactDefinitions(artifactDefinitionMap)
 	return r.flaws
 }
@@ -156,6 +162,7 @@ func (r *validator) validateArtifactDefinition(filename string, artifactDefiniti
 	r.validateArtifactOS(filename, artifactDefinition)
 	r.validateNoDefinitionConditions(filename, artifactDefinition)
 	r.validateNoDefinitionProvides(filename, artifactDefinition)
+	r.validateLabels(filename, artifactDefinition)
 	if macosArtifact {
 		r.validateMacOSDoublePath(filename, artifactDefinition)
 	}
@@ -301,10 +308,11 @@ func (r *validator) validateGroupMemberExist(artifactDefinitions []goartifacts.A
 		for _, source := range artifactDefinition.Sources {
 			for _, member := range source.Attributes.Names {
 				if _, ok := knownNames[member]; !ok {
-					r.addErrorf(
-						"", artifactDefinition.Name,
-						"Unknown name %s in %s", member, artifactDefinition.Name,
-					)
+					message := fmt.Sprintf("Unknown name %s in %s", member, artifactDefinition.Name)
+					if suggestions := goartifacts.SuggestNames(member, artifactDefinitions); len(suggestions) > 0 {
+						message += "; did you mean " + strings.Join(suggestions, " or ") + "?"
+					}
+					r.addErrorf("", artifactDefinition.Name, "%s", message)
 				}
 			}
 		}
@@ -577,6 +585,12 @@ func (r *validator) validateNoDefinitionProvides(filename string, artifactDefini
 	}
 }
 
+func (r *validator) validateLabels(filename string, artifactDefinition goartifacts.ArtifactDefinition) {
+	for _, label := range r.labels.Validate(artifactDefinition) {
+		r.addInfof(filename, artifactDefinition.Name, "Unknown label %s", label)
+	}
+}
+
 func (r *validator) validateMacOSDoublePath(filename string, artifactDefinition goartifacts.ArtifactDefinition) {
 	knownPaths := map[string]bool{}
 	prefixes := []string{"/var", "/tmp", "/etc"}
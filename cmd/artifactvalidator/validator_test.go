@@ -156,6 +156,7 @@ func TestValidator_validateMultipleArtifactDefinitions(t *testing.T) {
 		{"Cyclic tree", r.validateNoCycles, "no_cycles_1.yaml", []Flaw{{Error, "Cyclic artifact group: [TestA TestB]", "", ""}}},
 		{"Selfreference", r.validateNoCycles, "no_cycles_2.yaml", []Flaw{{Error, "Artifact group references itself", "Test", ""}}},
 		{"Member does not exist", r.validateGroupMemberExist, "group_member_exist.yaml", []Flaw{{Error, "Unknown name Unknown in Test", "Test", ""}}},
+		{"Member misspelled", r.validateGroupMemberExist, "group_member_suggestion.yaml", []Flaw{{Error, "Unknown name WindowsEventLog in Test; did you mean WindowsEventLogs?", "Test", ""}}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -187,6 +188,7 @@ func TestValidator_validateSingleArtifactDefinitions(t *testing.T) {
 		{"Different source types", r.validateNameTypeSuffix, "../valid/name_type_suffix_3.yaml", []Flaw{}},
 		{"Doc without empty line", r.validateDocLong, "doc_long.yaml", []Flaw{{Info, "Long docs should contain an empty line", "TestCommand", "doc_long.yaml"}}},
 		{"Unknown OS", r.validateArtifactOS, "artifact_os.yaml", []Flaw{{Warning, "OS Unknown is not valid", "UnknownTestCommand", "artifact_os.yaml"}}},
+		{"Unknown label", r.validateLabels, "labels.yaml", []Flaw{{Info, "Unknown label Unknown", "TestCommand", "labels.yaml"}}},
 		{"Only /etc", r.validateMacOSDoublePath, "mac_os_double_path_1.yaml", []Flaw{{Warning, "Found /etc but not /private/etc", "TestDirectory", "mac_os_double_path_1.yaml"}}},
 		{"Only /private/etc", r.validateMacOSDoublePath, "mac_os_double_path_2.yaml", []Flaw{{Warning, "Found /private/etc but not /etc", "TestDirectory", "mac_os_double_path_2.yaml"}}},
 		{"Both paths: /etc and /private/etc", r.validateMacOSDoublePath, "../valid/mac_os_double_path_3.yaml", []Flaw{}},
@@ -211,6 +213,18 @@ func TestValidator_validateSingleArtifactDefinitions(t *testing.T) {
 	}
 }
 
+func TestValidator_validateLabelsCustom(t *testing.T) {
+	ads, _, err := goartifacts.DecodeFile(filepath.Join("..", "..", "test", "artifacts", "invalid", "labels.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := goartifacts.DefaultLabels()
+	labels.Add(goartifacts.Label{Name: "Unknown", Description: "Custom label."})
+	if flaws := ValidateArtifactDefinitions(map[string][]goartifacts.ArtifactDefinition{"": ads}, labels); len(flaws) > 0 {
+		t.Errorf("ValidateArtifactDefinitions() = %v, want no flaws", flaws)
+	}
+}
+
 func TestValidator_validateSingleSource(t *testing.T) {
 	r := newValidator()
 	tests := []struct {
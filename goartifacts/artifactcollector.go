@@ -25,13 +25,28 @@ import (
 	"io/fs"
 )
 
-// ArtifactCollector is an interface that can resolve parameters in artifact
-// defintions and collect artifacts.
-type ArtifactCollector interface {
+// ExpansionTarget is an interface that can resolve parameters in artifact
+// definitions and provides the file system and registry sources are expanded
+// in. It is implemented by all collectors.
+type ExpansionTarget interface {
 	Resolve(parameter string) ([]string, error)
-	Collect(name string, source Source)
 
 	FS() fs.FS
 	Registry() fs.FS
 	Prefixes() []string
 }
+
+// ArtifactCollector is an interface that can resolve parameters in artifact
+// defintions and collect artifacts.
+type ArtifactCollector interface {
+	ExpansionTarget
+	Collect(name string, source Source)
+}
+
+// Result describes what a collector produced for a single source.
+type Result struct {
+	// Items are identifiers of the collected items, e.g. paths or keys.
+	Items []string `json:"items,omitempty"`
+	// Records describe the collected items in detail.
+	Records []Record `json:"records,omitempty"`
+}
@@ -74,6 +74,10 @@ type Source struct {
 	Conditions  []string   `yaml:"conditions,omitempty"`
 	SupportedOs []string   `yaml:"supported_os,omitempty"`
 	Provides    []Provide  `yaml:"provides,omitempty"`
+
+	// Expanded is set for sources whose paths, keys or key value pairs are
+	// already expanded, e.g. by Run. Expanding them returns them unchanged.
+	Expanded bool `yaml:"-" json:"-"`
 }
 
 // The ArtifactDefinition describes an object of digital archaeological interest.
@@ -86,6 +90,19 @@ type ArtifactDefinition struct {
 	Labels      []string `yaml:"labels,omitempty"`
 	SupportedOs []string `yaml:"supported_os,omitempty"`
 	Urls        []string `yaml:"urls,omitempty"`
+
+	Extensions Extensions `yaml:"extensions,omitempty"`
+}
+
+// Extensions contain attributes that are not part of the artifact definition
+// format, but are used by goartifacts.
+type Extensions struct {
+	// Exclude lists path and key patterns that are never collected for this
+	// artifact, see ExpandOptions.Exclude.
+	Exclude []string `yaml:"exclude,omitempty"`
+	// Volatility overrides the volatility class of all sources of the
+	// artifact, see ParseVolatility.
+	Volatility string `yaml:"volatility,omitempty"`
 }
 
 // SourceType is an enumeration of artifact definition source types.
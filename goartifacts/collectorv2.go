@@ -0,0 +1,75 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"context"
+)
+
+// ArtifactCollectorV2 is the successor of ArtifactCollector. Collect can be
+// cancelled and returns what was collected or an error.
+type ArtifactCollectorV2 interface {
+	ExpansionTarget
+	Collect(ctx context.Context, name string, source Source) (Result, error)
+}
+
+// AdaptCollector wraps an ArtifactCollector, so it can be used as an
+// ArtifactCollectorV2. The adapter does not collect if the context is done,
+// but cannot cancel a running Collect. It always returns an empty Result.
+func AdaptCollector(collector ArtifactCollector) ArtifactCollectorV2 {
+	return &collectorAdapter{collector}
+}
+
+type collectorAdapter struct {
+	ArtifactCollector
+}
+
+// Collect calls Collect of the wrapped ArtifactCollector.
+func (c *collectorAdapter) Collect(ctx context.Context, name string, source Source) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	c.ArtifactCollector.Collect(name, source)
+	return Result{}, nil
+}
+
+type sourceIndexKey struct{}
+
+// SourceIndex returns the index of the collected source in its artifact
+// definition. The index is set in the context that RunContext passes to
+// Collect.
+func SourceIndex(ctx context.Context) (int, bool) {
+	index, ok := ctx.Value(sourceIndexKey{}).(int)
+	return index, ok
+}
+
+// RunContext works like Run for an ArtifactCollectorV2. If the context is
+// cancelled, all remaining sources are skipped and the context's error is
+// returned. The context passed to Collect contains the index of the source,
+// see SourceIndex.
+func RunContext(ctx context.Context, artifactDefinitions []ArtifactDefinition, names []string, collector ArtifactCollectorV2, options RunOptions) (*RunReport, error) {
+	collectSource := func(name string, index int, source Source) (Result, error) {
+		return collector.Collect(context.WithValue(ctx, sourceIndexKey{}, index), name, source)
+	}
+	report := run(artifactDefinitions, names, collector, collectSource, ctx.Err, options)
+	return report, ctx.Err()
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testCollectorV2 struct {
+	TestCollector
+	cancel  func()
+	indexes []int
+}
+
+func (r *testCollectorV2) Collect(ctx context.Context, name string, source Source) (Result, error) {
+	if index, ok := SourceIndex(ctx); ok {
+		r.indexes = append(r.indexes, index)
+	}
+	if name == "C" {
+		return Result{}, errors.New("could not collect")
+	}
+	if name == "A" && r.cancel != nil {
+		r.cancel()
+	}
+	source = ExpandSource(source, r)
+	return Result{Items: source.Attributes.Paths}, nil
+}
+
+func TestRunContext(t *testing.T) {
+	collector := &testCollectorV2{TestCollector: TestCollector{fs: getInFS()}}
+	report, err := RunContext(context.Background(), getRunDefinitions(), []string{"Group"}, collector, RunOptions{OS: "Linux"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var statuses []Status
+	for _, artifact := range report.Artifacts {
+		statuses = append(statuses, artifact.Status)
+	}
+	if want := []Status{StatusFailed, StatusCollected, StatusCollected}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("RunContext() statuses = %v, want %v", statuses, want)
+	}
+	if report.Artifacts[0].Error != "could not collect" {
+		t.Errorf("RunContext() error = %v", report.Artifacts[0].Error)
+	}
+	if items := report.Artifacts[1].Sources[0].Result.Items; !reflect.DeepEqual(items, []string{"foo.bin"}) {
+		t.Errorf("RunContext() items = %v", items)
+	}
+}
+
+func TestRunContext_SourceIndex(t *testing.T) {
+	file := func(os ...string) Source {
+		return Source{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/foo.bin"}}, SupportedOs: os}
+	}
+	definitions := []ArtifactDefinition{{Name: "A", Sources: []Source{file("Windows"), file(), file("Linux")}}}
+
+	collector := &testCollectorV2{TestCollector: TestCollector{fs: getInFS()}}
+	if _, err := RunContext(context.Background(), definitions, []string{"A"}, collector, RunOptions{OS: "Linux"}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(collector.indexes, []int{1, 2}) {
+		t.Errorf("SourceIndex() = %v, want [1 2]", collector.indexes)
+	}
+	if _, ok := SourceIndex(context.Background()); ok {
+		t.Error("SourceIndex() found index in empty context")
+	}
+}
+
+func TestRunContext_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	collector := &testCollectorV2{TestCollector: TestCollector{fs: getInFS()}, cancel: cancel}
+	report, err := RunContext(ctx, getRunDefinitions(), []string{"A", "B"}, collector, RunOptions{OS: "Linux"})
+	if err != context.Canceled {
+		t.Errorf("RunContext() error = %v, want %v", err, context.Canceled)
+	}
+	if report.Artifacts[0].Status != StatusCollected || report.Artifacts[1].Status != StatusSkipped {
+		t.Errorf("RunContext() = %#v", report.Artifacts)
+	}
+}
+
+func TestAdaptCollector(t *testing.T) {
+	collector := &TestCollector{fs: getInFS()}
+	report, err := RunContext(context.Background(), getRunDefinitions(), []string{"A"}, AdaptCollector(collector), RunOptions{OS: "Linux"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Artifacts[0].Status != StatusCollected || len(collector.Collected["A"]) != 1 {
+		t.Errorf("RunContext() = %#v", report.Artifacts)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := AdaptCollector(collector).Collect(ctx, "A", Source{}); err != context.Canceled {
+		t.Errorf("Collect() error = %v, want %v", err, context.Canceled)
+	}
+}
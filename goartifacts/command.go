@@ -0,0 +1,298 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Defaults for CommandExecutor.
+const (
+	DefaultCommandTimeout = time.Minute
+	DefaultMaxOutput      = 10 << 20
+)
+
+// outputDelay is the time Execute waits for the output after the processes
+// of a timed out command were killed.
+const outputDelay = time.Second
+
+// ErrCommandNotAllowed is returned if a command is not allowed by the
+// allowlist or denylist of a CommandExecutor.
+var ErrCommandNotAllowed = errors.New("command not allowed")
+
+// CommandExecutor runs COMMAND sources. Commands are only executed if they
+// are allowed: if Allow is set, only the listed binaries can run, otherwise
+// all binaries except those in Deny. If neither is set, no command is run,
+// so untrusted definitions cannot execute arbitrary binaries.
+//
+// Entries of Allow and Deny are either binary names like "netstat" or
+// absolute paths, which match the resolved binary. Names in Allow only match
+// commands given by name and resolved via PATH, names in Deny match any
+// binary with that name.
+type CommandExecutor struct {
+	Allow []string
+	Deny  []string
+
+	// Timeout is the maximal duration of a single command, defaults to
+	// DefaultCommandTimeout.
+	Timeout time.Duration
+	// MaxOutput is the maximal number of bytes kept of stdout and stderr
+	// each, defaults to DefaultMaxOutput.
+	MaxOutput int
+	// Env is the environment of the commands. If nil, only PATH and on
+	// Windows SYSTEMROOT are passed from the current environment.
+	Env []string
+	// Dir is the working directory of the commands.
+	Dir string
+}
+
+// Execution is the result of an executed command.
+type Execution struct {
+	Result          CommandResult
+	Stdout          []byte
+	Stderr          []byte
+	StdoutTruncated bool
+	StderrTruncated bool
+	TimedOut        bool
+}
+
+// Execute runs the command of a COMMAND source. A non zero exit code or a
+// timeout is not an error, but recorded in the Execution. The command runs
+// in its own process group, on timeout all processes of the group are
+// killed, including background processes started by the command.
+func (e *CommandExecutor) Execute(ctx context.Context, source Source) (*Execution, error) {
+	if source.Type != SourceType.Command {
+		return nil, fmt.Errorf("cannot execute %s source", source.Type)
+	}
+	binary, err := e.lookup(source.Attributes.Cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	maxOutput := e.MaxOutput
+	if maxOutput <= 0 {
+		maxOutput = DefaultMaxOutput
+	}
+	stdout, stderr := &limitedBuffer{max: maxOutput}, &limitedBuffer{max: maxOutput}
+
+	cmd := exec.Command(binary, source.Attributes.Args...) // #nosec
+	cmd.Env = e.env()
+	cmd.Dir = e.Dir
+	setProcessGroup(cmd)
+
+	// The pipes are handled here instead of by exec.Cmd, so they can be
+	// closed if processes that inherited them outlive the timeout.
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stderrReader, stderrWriter, err := os.Pipe()
+	if err != nil {
+		stdoutReader.Close()
+		stdoutWriter.Close()
+		return nil, err
+	}
+	defer stdoutReader.Close()
+	defer stderrReader.Close()
+	cmd.Stdout, cmd.Stderr = stdoutWriter, stderrWriter
+
+	execution := &Execution{Result: CommandResult{
+		Argv:  append([]string{source.Attributes.Cmd}, source.Attributes.Args...),
+		Start: time.Now().UTC(),
+	}}
+	err = cmd.Start()
+	stdoutWriter.Close()
+	stderrWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var copying sync.WaitGroup
+	copying.Add(2)
+	copyOutput := func(w io.Writer, r io.Reader) {
+		defer copying.Done()
+		io.Copy(w, r) // nolint:errcheck
+	}
+	go copyOutput(stdout, stdoutReader)
+	go copyOutput(stderr, stderrReader)
+	copied := make(chan struct{})
+	go func() {
+		copying.Wait()
+		close(copied)
+	}()
+
+	exited := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd.Process)
+		case <-exited:
+		}
+	}()
+	err = cmd.Wait()
+	close(exited)
+
+	// processes that inherited the pipes can keep them open after the
+	// command exited
+	select {
+	case <-copied:
+	case <-ctx.Done():
+		killProcessGroup(cmd.Process)
+		select {
+		case <-copied:
+		case <-time.After(outputDelay):
+			stdoutReader.Close()
+			stderrReader.Close()
+			<-copied
+		}
+	}
+	execution.Result.Duration = time.Since(execution.Result.Start)
+	execution.Stdout, execution.StdoutTruncated = stdout.Bytes(), stdout.truncated
+	execution.Stderr, execution.StderrTruncated = stderr.Bytes(), stderr.truncated
+
+	if ctx.Err() == context.DeadlineExceeded {
+		execution.TimedOut = true
+	}
+	execution.Result.ExitCode = exitCode(cmd.ProcessState)
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		return execution, err
+	}
+	return execution, nil
+}
+
+// lookup resolves the binary of a command and checks if it may run.
+func (e *CommandExecutor) lookup(command string) (string, error) {
+	if command == "" {
+		return "", errors.New("empty command")
+	}
+	if len(e.Allow) == 0 && len(e.Deny) == 0 {
+		return "", ErrCommandNotAllowed
+	}
+
+	binary, err := exec.LookPath(command)
+	if err != nil {
+		return "", err
+	}
+	if binary, err = filepath.Abs(binary); err != nil {
+		return "", err
+	}
+
+	byName := !strings.ContainsAny(command, `/\`)
+	match := func(entries []string, anyName bool) bool {
+		for _, entry := range entries {
+			switch {
+			case filepath.IsAbs(entry):
+				if sameFile(filepath.Clean(entry), binary) {
+					return true
+				}
+			case byName && sameFile(trimExt(entry), trimExt(command)):
+				return true
+			case anyName && sameFile(trimExt(entry), trimExt(filepath.Base(binary))):
+				return true
+			}
+		}
+		return false
+	}
+
+	if match(e.Deny, true) || (len(e.Allow) > 0 && !match(e.Allow, false)) {
+		return "", ErrCommandNotAllowed
+	}
+	return binary, nil
+}
+
+func (e *CommandExecutor) env() []string {
+	if e.Env != nil {
+		return e.Env
+	}
+	env := []string{"PATH=" + os.Getenv("PATH")}
+	if runtime.GOOS == windows {
+		env = append(env, "SYSTEMROOT="+os.Getenv("SYSTEMROOT"))
+	}
+	return env
+}
+
+func sameFile(a, b string) bool {
+	if runtime.GOOS == windows {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+func trimExt(name string) string {
+	if runtime.GOOS == windows {
+		return strings.TrimSuffix(strings.ToLower(name), ".exe")
+	}
+	return name
+}
+
+func exitCode(state *os.ProcessState) int {
+	if state == nil {
+		return -1
+	}
+	if status, ok := state.Sys().(syscall.WaitStatus); ok {
+		return status.ExitStatus()
+	}
+	if state.Success() {
+		return 0
+	}
+	return -1
+}
+
+// limitedBuffer keeps the first max bytes written and discards the rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if remaining := b.max - b.buf.Len(); len(p) > remaining {
+		p = p[:remaining]
+		b.truncated = true
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+func (b *limitedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
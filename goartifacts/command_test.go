@@ -0,0 +1,139 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+//go:build !windows
+// +build !windows
+
+package goartifacts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func command(cmd string, args ...string) Source {
+	return Source{Type: SourceType.Command, Attributes: Attributes{Cmd: cmd, Args: args}}
+}
+
+func TestCommandExecutorAllow(t *testing.T) {
+	sh, err := filepath.Abs("/bin/sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		executor CommandExecutor
+		source   Source
+		wantErr  bool
+	}{
+		{"no policy", CommandExecutor{}, command("echo"), true},
+		{"allowed", CommandExecutor{Allow: []string{"echo"}}, command("echo"), false},
+		{"not allowed", CommandExecutor{Allow: []string{"echo"}}, command("sh", "-c", "echo"), true},
+		{"path for name", CommandExecutor{Allow: []string{"sh"}}, command(sh, "-c", "true"), true},
+		{"path", CommandExecutor{Allow: []string{sh}}, command(sh, "-c", "true"), false},
+		{"denied", CommandExecutor{Deny: []string{"sh"}}, command("sh", "-c", "true"), true},
+		{"denied path", CommandExecutor{Deny: []string{sh}}, command(sh, "-c", "true"), true},
+		{"denied name", CommandExecutor{Deny: []string{"sh"}}, command(sh, "-c", "true"), true},
+		{"not denied", CommandExecutor{Deny: []string{"sh"}}, command("echo"), false},
+		{"wrong type", CommandExecutor{Allow: []string{"echo"}}, Source{Type: SourceType.File}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.executor.Execute(context.Background(), tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommandExecutorExecute(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	executor := CommandExecutor{
+		Allow:     []string{"sh"},
+		Env:       []string{"PATH=" + os.Getenv("PATH"), "FOO=bar"},
+		Dir:       filepath.Dir(dir),
+		MaxOutput: 8,
+		Timeout:   time.Second,
+	}
+
+	execution, err := executor.Execute(context.Background(), command("sh", "-c", `echo "$FOO $(pwd)"; echo err >&2; exit 3`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if execution.Result.ExitCode != 3 || string(execution.Stderr) != "err\n" || execution.StderrTruncated {
+		t.Errorf("unexpected execution %#v", execution)
+	}
+	if want := ("bar " + filepath.Dir(dir))[:8]; string(execution.Stdout) != want || !execution.StdoutTruncated {
+		t.Errorf("stdout = %q, want %q", execution.Stdout, want)
+	}
+	if strings.Join(execution.Result.Argv, " ") != "sh -c "+`echo "$FOO $(pwd)"; echo err >&2; exit 3` {
+		t.Errorf("argv = %v", execution.Result.Argv)
+	}
+
+	executor.Timeout = 100 * time.Millisecond
+	execution, err = executor.Execute(context.Background(), command("sh", "-c", "exec sleep 10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !execution.TimedOut || execution.Result.ExitCode == 0 || execution.Result.Duration > 5*time.Second {
+		t.Errorf("unexpected execution %#v", execution)
+	}
+}
+
+func TestCommandExecutorTimeoutBackground(t *testing.T) {
+	executor := CommandExecutor{Allow: []string{"sh"}, Timeout: 200 * time.Millisecond}
+
+	tests := []struct {
+		name string
+		cmd  string
+	}{
+		{"running", "sleep 4 & sleep 10"},
+		{"exited", "sleep 10 & echo started"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			execution, err := executor.Execute(context.Background(), command("sh", "-c", tt.cmd))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !execution.TimedOut || time.Since(start) > 3*time.Second {
+				t.Errorf("Execute() took %s, timed out %v", time.Since(start), execution.TimedOut)
+			}
+		})
+	}
+
+	execution, err := executor.Execute(context.Background(), command("sh", "-c", "echo foo; echo bar >&2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if execution.TimedOut || string(execution.Stdout) != "foo\n" || string(execution.Stderr) != "bar\n" {
+		t.Errorf("unexpected execution %#v", execution)
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+// Package custody records a chain-of-custody manifest during a collection.
+// The manifest contains the hash of the definition set, the tool version,
+// host and target identifiers, a timeline of all Collect calls and hashes of
+// all collected files. It is signed with an Ed25519 key and can be verified
+// against the collected items.
+package custody
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/forensicanalysis/artifactlib/goartifacts"
+)
+
+// Version is the version of the manifest format.
+const Version = 1
+
+// Manifest is a chain-of-custody record of a collection.
+type Manifest struct {
+	Version     int       `json:"version"`
+	Tool        string    `json:"tool"`
+	Host        string    `json:"host"`
+	Target      string    `json:"target"`
+	Definitions string    `json:"definitions"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Timeline    []Event   `json:"timeline"`
+	Items       []Item    `json:"items"`
+}
+
+// Event records a single call of Collect.
+type Event struct {
+	Artifact string `json:"artifact"`
+	// Index is the index of the source in the artifact definition or -1
+	// if it is unknown, see goartifacts.SourceIndex.
+	Index int       `json:"index"`
+	Type  string    `json:"type"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Items int       `json:"items"`
+	Error string    `json:"error,omitempty"`
+}
+
+// Item is a collected file.
+type Item struct {
+	Artifact string             `json:"artifact"`
+	Path     string             `json:"path"`
+	Export   string             `json:"export,omitempty"`
+	Size     int64              `json:"size"`
+	Hashes   goartifacts.Hashes `json:"hashes"`
+}
+
+// Options describe the collection.
+type Options struct {
+	// Tool identifies the collecting tool and its version.
+	Tool string
+	// Host identifies the host the collection runs on, defaults to the
+	// hostname.
+	Host string
+	// Target identifies the collected system, e.g. an image or a hostname.
+	Target string
+}
+
+// Recorder is an ArtifactCollectorV2 that wraps another collector, e.g. a
+// zipcollector.Collector, and records the manifest. Files are recorded from
+// the FileResult records of the wrapped collector.
+type Recorder struct {
+	goartifacts.ArtifactCollectorV2
+	manifest Manifest
+}
+
+// NewRecorder creates a Recorder for a collection of the given artifact
+// definitions.
+func NewRecorder(collector goartifacts.ArtifactCollectorV2, artifactDefinitions []goartifacts.ArtifactDefinition, options Options) (*Recorder, error) {
+	definitions, err := goartifacts.HashDefinitions(artifactDefinitions)
+	if err != nil {
+		return nil, err
+	}
+	if options.Host == "" {
+		options.Host, err = os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Recorder{
+		ArtifactCollectorV2: collector,
+		manifest: Manifest{
+			Version:     Version,
+			Tool:        options.Tool,
+			Host:        options.Host,
+			Target:      options.Target,
+			Definitions: definitions,
+			Start:       time.Now().UTC(),
+			Timeline:    []Event{},
+			Items:       []Item{},
+		},
+	}, nil
+}
+
+// Collect collects the source with the wrapped collector and records the
+// call in the timeline. The result and error of the wrapped collector are
+// returned unmodified.
+func (r *Recorder) Collect(ctx context.Context, name string, source goartifacts.Source) (goartifacts.Result, error) {
+	index, ok := goartifacts.SourceIndex(ctx)
+	if !ok {
+		index = -1
+	}
+	event := Event{Artifact: name, Index: index, Type: source.Type, Start: time.Now().UTC()}
+	result, err := r.ArtifactCollectorV2.Collect(ctx, name, source)
+	event.End = time.Now().UTC()
+	if err != nil {
+		event.Error = err.Error()
+	}
+	event.Items = len(result.Items)
+	r.manifest.Timeline = append(r.manifest.Timeline, event)
+
+	for _, record := range result.Records {
+		if file, ok := record.Data.(*goartifacts.FileResult); ok {
+			r.manifest.Items = append(r.manifest.Items, Item{
+				Artifact: name,
+				Path:     file.Path,
+				Export:   file.Export,
+				Size:     file.Size,
+				Hashes:   file.Hashes,
+			})
+		}
+	}
+	return result, err
+}
+
+// Manifest finishes the manifest and returns it.
+func (r *Recorder) Manifest() *Manifest {
+	r.manifest.End = time.Now().UTC()
+	manifest := r.manifest
+	return &manifest
+}
+
+// SignedManifest is a manifest and its signature. The signature is computed
+// over the manifest bytes as they are stored.
+type SignedManifest struct {
+	Manifest  json.RawMessage `json:"manifest"`
+	Signature []byte          `json:"signature"`
+}
+
+// Sign signs the manifest with the private key.
+func Sign(manifest *Manifest, key ed25519.PrivateKey) (*SignedManifest, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid private key")
+	}
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedManifest{Manifest: b, Signature: ed25519.Sign(key, b)}, nil
+}
+
+// ErrInvalidSignature is returned by Verify if the manifest was not signed
+// with the key or was modified after signing.
+var ErrInvalidSignature = errors.New("invalid manifest signature")
+
+// TamperError lists collected items that do not match the manifest.
+type TamperError struct {
+	Problems []string
+}
+
+func (e *TamperError) Error() string {
+	return fmt.Sprintf("collected items do not match manifest: %v", e.Problems)
+}
+
+// Verify checks the signature of the manifest with the public key and
+// returns the manifest. If items is not nil, all items with an export
+// location are hashed again and compared with the manifest, mismatches are
+// returned as *TamperError. Items without an export location cannot be
+// checked. Files in items that are not listed in the manifest, like the
+// manifest of the collector or files added later, are not detected.
+func Verify(signed *SignedManifest, key ed25519.PublicKey, items fs.FS) (*Manifest, error) {
+	if len(key) != ed25519.PublicKeySize || !ed25519.Verify(key, signed.Manifest, signed.Signature) {
+		return nil, ErrInvalidSignature
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(signed.Manifest, manifest); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		return manifest, nil
+	}
+
+	var problems []string
+	for _, item := range manifest.Items {
+		if item.Export == "" {
+			continue
+		}
+		size, hashes, err := goartifacts.HashFile(items, item.Export)
+		switch {
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("%s: %s", item.Export, err))
+		case size != item.Size:
+			problems = append(problems, fmt.Sprintf("%s: size %d, manifest %d", item.Export, size, item.Size))
+		case !matches(hashes, item.Hashes):
+			problems = append(problems, fmt.Sprintf("%s: hashes do not match", item.Export))
+		}
+	}
+	if len(problems) > 0 {
+		return manifest, &TamperError{Problems: problems}
+	}
+	return manifest, nil
+}
+
+// matches compares the hashes that are set in the manifest.
+func matches(got, want goartifacts.Hashes) bool {
+	if want.MD5 == "" && want.SHA1 == "" && want.SHA256 == "" {
+		return false
+	}
+	return (want.MD5 == "" || got.MD5 == want.MD5) &&
+		(want.SHA1 == "" || got.SHA1 == want.SHA1) &&
+		(want.SHA256 == "" || got.SHA256 == want.SHA256)
+}
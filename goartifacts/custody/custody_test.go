@@ -0,0 +1,139 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package custody
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"testing"
+	"testing/fstest"
+
+	"github.com/forensicanalysis/artifactlib/goartifacts"
+	"github.com/forensicanalysis/artifactlib/goartifacts/zipcollector"
+)
+
+func collect(t *testing.T) (*SignedManifest, *zip.Reader, ed25519.PublicKey) {
+	fsys := fstest.MapFS{
+		"etc/passwd": &fstest.MapFile{Data: []byte("foo")},
+		"etc/group":  &fstest.MapFile{Data: []byte("bar")},
+	}
+	definitions := []goartifacts.ArtifactDefinition{
+		{Name: "Passwd", Sources: []goartifacts.Source{{Type: goartifacts.SourceType.File, Attributes: goartifacts.Attributes{Paths: []string{"/etc/passwd"}}}}},
+		{Name: "Group", Sources: []goartifacts.Source{
+			{Type: goartifacts.SourceType.File, Attributes: goartifacts.Attributes{Paths: []string{"/etc/group"}}},
+			{Type: goartifacts.SourceType.Command, Attributes: goartifacts.Attributes{Cmd: "id"}},
+		}},
+	}
+
+	buf := &bytes.Buffer{}
+	collector := zipcollector.New(buf, fsys, zipcollector.Options{})
+	recorder, err := NewRecorder(collector, definitions, Options{Tool: "test 1.0", Host: "host", Target: "image.dd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := goartifacts.RunContext(context.Background(), definitions, []string{"Passwd", "Group"}, recorder, goartifacts.RunOptions{OS: "Linux"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Name != "Group" || failed[0].Sources[1].Status != goartifacts.StatusFailed {
+		t.Errorf("failed = %#v, want failed Group command", failed)
+	}
+	if err := collector.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := Sign(recorder.Manifest(), private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed, archive, public
+}
+
+func TestVerify(t *testing.T) {
+	signed, archive, public := collect(t)
+
+	manifest, err := Verify(signed, public, archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Tool != "test 1.0" || manifest.Host != "host" || manifest.Target != "image.dd" || manifest.Definitions == "" {
+		t.Errorf("unexpected manifest header %#v", manifest)
+	}
+	if len(manifest.Timeline) != 3 {
+		t.Fatalf("timeline = %#v, want 3 events", manifest.Timeline)
+	}
+	if event := manifest.Timeline[2]; event.Artifact != "Group" || event.Index != 1 || event.Type != goartifacts.SourceType.Command || event.Error == "" {
+		t.Errorf("unexpected event %#v", event)
+	}
+	for _, event := range manifest.Timeline {
+		if event.Start.Location().String() != "UTC" || event.End.Before(event.Start) {
+			t.Errorf("unexpected event time %#v", event)
+		}
+	}
+	if len(manifest.Items) != 2 || manifest.Items[0].Hashes.SHA256 != "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae" {
+		t.Errorf("unexpected items %#v", manifest.Items)
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	signed, archive, public := collect(t)
+
+	otherPublic, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(signed, otherPublic, nil); err != ErrInvalidSignature {
+		t.Errorf("Verify() with other key error = %v, want %v", err, ErrInvalidSignature)
+	}
+
+	modified := *signed
+	modified.Manifest = bytes.Replace(signed.Manifest, []byte("image.dd"), []byte("other.dd"), 1)
+	if _, err := Verify(&modified, public, nil); err != ErrInvalidSignature {
+		t.Errorf("Verify() of modified manifest error = %v, want %v", err, ErrInvalidSignature)
+	}
+
+	items := fstest.MapFS{
+		"files/etc/passwd": &fstest.MapFile{Data: []byte("FOO")},
+	}
+	_, err = Verify(signed, public, items)
+	tamperErr, ok := err.(*TamperError)
+	if !ok {
+		t.Fatalf("Verify() of modified items error = %v, want TamperError", err)
+	}
+	if len(tamperErr.Problems) != 2 {
+		t.Errorf("problems = %v, want modified passwd and missing group", tamperErr.Problems)
+	}
+
+	if _, err := Verify(signed, public, archive); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"strings"
+)
+
+// ItemReport lists all artifacts and sources that requested an item in a
+// run with RunOptions.Deduplicate.
+type ItemReport struct {
+	Type    string       `json:"type"`
+	Item    string       `json:"item"`
+	Sources []ItemSource `json:"sources"`
+}
+
+// ItemSource identifies a source of an artifact.
+type ItemSource struct {
+	Artifact string `json:"artifact"`
+	Index    int    `json:"index"`
+}
+
+// deduplicator removes items that were already collected in a run. Items
+// are identified by the source type and the lower case expanded path or
+// key, like expandPath does within a single source.
+type deduplicator struct {
+	items  map[string]int
+	report *RunReport
+}
+
+func newDeduplicator(report *RunReport) *deduplicator {
+	return &deduplicator{items: map[string]int{}, report: report}
+}
+
+// filter returns the items that were not requested before and those that
+// were. All requests are recorded in the report.
+func (d *deduplicator) filter(artifact string, index int, sourceType string, items []string) (remaining, duplicates []string) {
+	itemSource := ItemSource{Artifact: artifact, Index: index}
+	for _, item := range items {
+		key := sourceType + ":" + strings.ToLower(item)
+		if i, ok := d.items[key]; ok {
+			d.report.Items[i].Sources = append(d.report.Items[i].Sources, itemSource)
+			duplicates = append(duplicates, item)
+			continue
+		}
+		d.items[key] = len(d.report.Items)
+		d.report.Items = append(d.report.Items, ItemReport{Type: sourceType, Item: item, Sources: []ItemSource{itemSource}})
+		remaining = append(remaining, item)
+	}
+	return remaining, duplicates
+}
+
+// sourceItems expands a source into its items: paths, keys or key and value
+// name separated by a backslash. If options are given, they are applied and
+// the excluded paths and keys are returned. ok is false if the source cannot
+// be expanded into items or does not expand to any.
+func sourceItems(source Source, target ExpansionTarget, options *ExpandOptions) (items []string, excluded []ExcludedPath, ok bool) {
+	if !hasItems(source.Type) {
+		return nil, nil, false
+	}
+	expansion := expandSource(source, target, options)
+	switch source.Type {
+	case SourceType.File, SourceType.Directory, SourceType.Path:
+		items = expansion.Source.Attributes.Paths
+	case SourceType.RegistryKey:
+		items = expansion.Source.Attributes.Keys
+	case SourceType.RegistryValue:
+		for _, pair := range expansion.Source.Attributes.KeyValuePairs {
+			items = append(items, pair.Key+"\\"+pair.Value)
+		}
+	}
+	return items, expansion.Excluded, len(items) > 0
+}
+
+// hasItems returns if sources of the type can be expanded into items by
+// sourceItems.
+func hasItems(sourceType string) bool {
+	switch sourceType {
+	case SourceType.File, SourceType.Directory, SourceType.Path, SourceType.RegistryKey, SourceType.RegistryValue:
+		return true
+	}
+	return false
+}
+
+// withItems returns the source with the given items from sourceItems. The
+// source is marked as expanded, so the items are not expanded again.
+func withItems(source Source, items []string) Source {
+	source.Expanded = true
+	switch source.Type {
+	case SourceType.File, SourceType.Directory, SourceType.Path:
+		source.Attributes.Paths, source.Attributes.Separator = items, ""
+	case SourceType.RegistryKey:
+		source.Attributes.Keys = items
+	case SourceType.RegistryValue:
+		source.Attributes.KeyValuePairs = nil
+		for _, item := range items {
+			// expanded keys do not contain backslashes
+			parts := strings.SplitN(item, "\\", 2)
+			source.Attributes.KeyValuePairs = append(source.Attributes.KeyValuePairs, KeyValuePair{Key: parts[0], Value: parts[1]})
+		}
+	}
+	return source
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+type prefixCollector struct {
+	TestCollector
+	prefixes []string
+}
+
+func (r *prefixCollector) Collect(name string, source Source) {
+	if r.Collected == nil {
+		r.Collected = map[string][]Source{}
+	}
+	r.Collected[name] = append(r.Collected[name], ExpandSource(source, r))
+}
+
+func (r *prefixCollector) Prefixes() []string {
+	return r.prefixes
+}
+
+func TestRunDeduplicatePrefixes(t *testing.T) {
+	fsys := fstest.MapFS{"p1/foo.bin": &fstest.MapFile{}, "p3/Windows/x.bin": &fstest.MapFile{}, "p3/Windows/y.bin": &fstest.MapFile{}}
+	files := func(paths ...string) Source {
+		return Source{Type: SourceType.File, Attributes: Attributes{Paths: paths}}
+	}
+	definitions := []ArtifactDefinition{
+		{Name: "A", Sources: []Source{files(`\Windows\x.bin`)}},
+		{Name: "B", Sources: []Source{files(`\Windows\*.bin`, `\foo.bin`)}},
+	}
+
+	collector := &prefixCollector{TestCollector: TestCollector{fs: fsys}, prefixes: []string{"p1", "p3"}}
+	Run(definitions, []string{"A", "B"}, collector, RunOptions{OS: "Windows", Deduplicate: true})
+
+	var collected []string
+	for _, name := range []string{"A", "B"} {
+		for _, source := range collector.Collected[name] {
+			for _, p := range source.Attributes.Paths {
+				collected = append(collected, name+" "+p)
+			}
+		}
+	}
+	want := []string{"A p3/Windows/x.bin", "B p3/Windows/y.bin", "B p1/foo.bin"}
+	if !reflect.DeepEqual(collected, want) {
+		t.Errorf("Run() collected = %v, want %v", collected, want)
+	}
+}
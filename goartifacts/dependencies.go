@@ -0,0 +1,165 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"regexp"
+	"sort"
+)
+
+var parameterRegex = regexp.MustCompile(`%%([^%]+)%%`)
+
+// Parameters returns the knowledge base parameters like users.appdata that
+// are used in the paths and keys of a source.
+func Parameters(source Source) []string {
+	var values []string
+	values = append(values, source.Attributes.Paths...)
+	values = append(values, source.Attributes.Keys...)
+	for _, pair := range source.Attributes.KeyValuePairs {
+		values = append(values, pair.Key)
+	}
+
+	var parameters []string
+	seen := map[string]bool{}
+	for _, value := range values {
+		for _, match := range parameterRegex.FindAllStringSubmatch(value, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				parameters = append(parameters, match[1])
+			}
+		}
+	}
+	return parameters
+}
+
+// MissingParameter is a parameter without a provider.
+type MissingParameter struct {
+	Parameter string `json:"parameter"`
+	// Artifacts lists the artifacts that use the parameter.
+	Artifacts []string `json:"artifacts"`
+}
+
+// Dependencies is the result of ResolveDependencies.
+type Dependencies struct {
+	// Artifacts contains the selected artifacts and their providers. Every
+	// provider precedes the artifacts that use its parameters, unless they
+	// depend on each other.
+	Artifacts []ArtifactDefinition `json:"artifacts"`
+	// Added lists the providers that were not selected.
+	Added []string `json:"added,omitempty"`
+	// Providers maps all used parameters to their providers.
+	Providers map[string][]string `json:"providers"`
+	// Missing lists used parameters without providers, sorted by name.
+	Missing []MissingParameter `json:"missing,omitempty"`
+}
+
+// ResolveDependencies computes the artifacts that provide the parameters
+// used by the selected artifacts on the operating system, including the
+// providers of parameters the providers use themselves. Only artifacts and
+// sources that support the operating system are considered.
+func ResolveDependencies(selected []ArtifactDefinition, artifactDefinitions []ArtifactDefinition, operatingSystem string) *Dependencies {
+	providers := map[string][]ArtifactDefinition{}
+	for _, artifactDefinition := range artifactDefinitions {
+		if !IsOSArtifactDefinition(operatingSystem, artifactDefinition.SupportedOs) {
+			continue
+		}
+		for _, key := range provides(artifactDefinition, operatingSystem) {
+			providers[key] = append(providers[key], artifactDefinition)
+		}
+	}
+
+	dependencies := &Dependencies{Providers: map[string][]string{}}
+	isSelected := map[string]bool{}
+	for _, artifactDefinition := range selected {
+		isSelected[artifactDefinition.Name] = true
+	}
+	missing := map[string][]string{}
+	visited := map[string]bool{}
+
+	var visit func(artifactDefinition ArtifactDefinition)
+	visit = func(artifactDefinition ArtifactDefinition) {
+		if visited[artifactDefinition.Name] {
+			return
+		}
+		visited[artifactDefinition.Name] = true
+
+		for _, source := range artifactDefinition.Sources {
+			if !IsOSArtifactDefinition(operatingSystem, source.SupportedOs) {
+				continue
+			}
+			for _, parameter := range Parameters(source) {
+				parameterProviders, ok := providers[parameter]
+				if !ok {
+					if !containsString(missing[parameter], artifactDefinition.Name) {
+						missing[parameter] = append(missing[parameter], artifactDefinition.Name)
+					}
+					continue
+				}
+				if _, ok := dependencies.Providers[parameter]; !ok {
+					for _, provider := range parameterProviders {
+						dependencies.Providers[parameter] = append(dependencies.Providers[parameter], provider.Name)
+					}
+				}
+				for _, provider := range parameterProviders {
+					visit(provider)
+				}
+			}
+		}
+
+		dependencies.Artifacts = append(dependencies.Artifacts, artifactDefinition)
+		if !isSelected[artifactDefinition.Name] {
+			dependencies.Added = append(dependencies.Added, artifactDefinition.Name)
+		}
+	}
+	for _, artifactDefinition := range selected {
+		visit(artifactDefinition)
+	}
+
+	for parameter, artifacts := range missing {
+		dependencies.Missing = append(dependencies.Missing, MissingParameter{Parameter: parameter, Artifacts: artifacts})
+	}
+	sort.Sort(byParameter(dependencies.Missing))
+	return dependencies
+}
+
+// provides returns the knowledge base keys an artifact provides on an
+// operating system.
+func provides(artifactDefinition ArtifactDefinition, operatingSystem string) []string {
+	keys := appendString(nil, artifactDefinition.Provides...)
+	for _, source := range artifactDefinition.Sources {
+		if !IsOSArtifactDefinition(operatingSystem, source.SupportedOs) {
+			continue
+		}
+		for _, provide := range source.Provides {
+			if !containsString(keys, provide.Key) {
+				keys = append(keys, provide.Key)
+			}
+		}
+	}
+	return keys
+}
+
+type byParameter []MissingParameter
+
+func (a byParameter) Len() int           { return len(a) }
+func (a byParameter) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byParameter) Less(i, j int) bool { return a[i].Parameter < a[j].Parameter }
@@ -0,0 +1,104 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func getDependencyDefinitions() []ArtifactDefinition {
+	file := func(path string, os ...string) Source {
+		return Source{Type: SourceType.File, Attributes: Attributes{Paths: []string{path}}, SupportedOs: os}
+	}
+	provider := func(key string, os ...string) Source {
+		return Source{Type: SourceType.RegistryKey, Attributes: Attributes{Keys: []string{`HKEY_LOCAL_MACHINE\%%environ_systemroot%%`}}, Provides: []Provide{{Key: key}}, SupportedOs: os}
+	}
+	return []ArtifactDefinition{
+		{Name: "Browser", Sources: []Source{file(`%%users.appdata%%\Browser\History`), file(`%%users.homedir%%/.browser`, "Linux")}},
+		{Name: "ShellFolders", Sources: []Source{provider("users.appdata")}, SupportedOs: []string{"Windows"}},
+		{Name: "ProfileList", Sources: []Source{provider("users.appdata"), provider("users.homedir", "Linux")}},
+		{Name: "SystemRoot", Sources: []Source{{Type: SourceType.RegistryValue, Attributes: Attributes{KeyValuePairs: []KeyValuePair{{Key: `HKEY_LOCAL_MACHINE\%%current_control_set%%`, Value: "SystemRoot"}}}, Provides: []Provide{{Key: "environ_systemroot"}}}}},
+		{Name: "Self", Provides: []string{"self"}, Sources: []Source{file(`%%self%%\foo`)}},
+	}
+}
+
+func TestResolveDependencies(t *testing.T) {
+	definitions := getDependencyDefinitions()
+
+	dependencies := ResolveDependencies(definitions[:1], definitions, "Windows")
+	var names []string
+	for _, artifactDefinition := range dependencies.Artifacts {
+		names = append(names, artifactDefinition.Name)
+	}
+	if want := []string{"SystemRoot", "ShellFolders", "ProfileList", "Browser"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ResolveDependencies() = %v, want %v", names, want)
+	}
+	if want := []string{"SystemRoot", "ShellFolders", "ProfileList"}; !reflect.DeepEqual(dependencies.Added, want) {
+		t.Errorf("ResolveDependencies() added = %v, want %v", dependencies.Added, want)
+	}
+	wantProviders := map[string][]string{
+		"users.appdata":      {"ShellFolders", "ProfileList"},
+		"environ_systemroot": {"SystemRoot"},
+	}
+	if !reflect.DeepEqual(dependencies.Providers, wantProviders) {
+		t.Errorf("ResolveDependencies() providers = %v, want %v", dependencies.Providers, wantProviders)
+	}
+	wantMissing := []MissingParameter{{Parameter: "current_control_set", Artifacts: []string{"SystemRoot"}}}
+	if !reflect.DeepEqual(dependencies.Missing, wantMissing) {
+		t.Errorf("ResolveDependencies() missing = %v, want %v", dependencies.Missing, wantMissing)
+	}
+
+	dependencies = ResolveDependencies([]ArtifactDefinition{definitions[0], definitions[4]}, definitions, "Linux")
+	names = nil
+	for _, artifactDefinition := range dependencies.Artifacts {
+		names = append(names, artifactDefinition.Name)
+	}
+	if want := []string{"SystemRoot", "ProfileList", "Browser", "Self"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ResolveDependencies() = %v, want %v", names, want)
+	}
+}
+
+func TestRunDependencies(t *testing.T) {
+	collector := &TestCollector{fs: getInFS()}
+	report := Run(getDependencyDefinitions(), []string{"Browser"}, collector, RunOptions{OS: "Windows", Dependencies: true})
+	var names []string
+	for _, artifact := range report.Artifacts {
+		names = append(names, artifact.Name)
+	}
+	if want := []string{"SystemRoot", "ShellFolders", "ProfileList", "Browser"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Run() = %v, want %v", names, want)
+	}
+	if len(report.MissingParameters) != 1 {
+		t.Errorf("Run() missing = %v", report.MissingParameters)
+	}
+}
+
+func TestParameters(t *testing.T) {
+	source := Source{Attributes: Attributes{
+		Paths: []string{`%%users.homedir%%\%%users.username%%`, `%%users.homedir%%`, `%SystemRoot%`},
+		Keys:  []string{`HKEY_USERS\%%users.sid%%`},
+	}}
+	if got, want := Parameters(source), []string{"users.homedir", "users.username", "users.sid"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Parameters() = %v, want %v", got, want)
+	}
+}
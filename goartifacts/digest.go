@@ -0,0 +1,97 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"bytes"
+	"crypto/md5"  // #nosec
+	"crypto/sha1" // #nosec
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// HashDefinitions returns a hex encoded SHA-256 hash of a set of artifact
+// definitions. The hash does not depend on the order of the definitions, so
+// it identifies the definition set used for a collection.
+func HashDefinitions(artifactDefinitions []ArtifactDefinition) (string, error) {
+	type marshaled struct {
+		definition ArtifactDefinition
+		b          []byte
+	}
+	sorted := make([]marshaled, len(artifactDefinitions))
+	for i, artifactDefinition := range artifactDefinitions {
+		b, err := yaml.Marshal(artifactDefinition)
+		if err != nil {
+			return "", err
+		}
+		sorted[i] = marshaled{artifactDefinition, b}
+	}
+	// definitions with the same name are ordered by their content
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].definition.Name != sorted[j].definition.Name {
+			return sorted[i].definition.Name < sorted[j].definition.Name
+		}
+		return bytes.Compare(sorted[i].b, sorted[j].b) < 0
+	})
+
+	definitions := make([]ArtifactDefinition, len(sorted))
+	for i := range sorted {
+		definitions[i] = sorted[i].definition
+	}
+	b, err := yaml.Marshal(definitions)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// HashFile returns the size and the hashes of a file.
+func HashFile(fsys fs.FS, name string) (int64, Hashes, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return 0, Hashes{}, err
+	}
+	defer f.Close()
+	return HashCopy(ioutil.Discard, f)
+}
+
+// HashCopy copies r to w and returns the number of copied bytes and their
+// MD5, SHA-1 and SHA-256 hashes.
+func HashCopy(w io.Writer, r io.Reader) (int64, Hashes, error) {
+	md5Hash, sha1Hash, sha256Hash := md5.New(), sha1.New(), sha256.New() // #nosec
+	size, err := io.Copy(io.MultiWriter(w, md5Hash, sha1Hash, sha256Hash), r)
+	if err != nil {
+		return size, Hashes{}, err
+	}
+	return size, Hashes{
+		MD5:    hex.EncodeToString(md5Hash.Sum(nil)),
+		SHA1:   hex.EncodeToString(sha1Hash.Sum(nil)),
+		SHA256: hex.EncodeToString(sha256Hash.Sum(nil)),
+	}, nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestHashDefinitions(t *testing.T) {
+	a := ArtifactDefinition{Name: "A", Sources: []Source{{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/foo.bin"}}}}}
+	b := ArtifactDefinition{Name: "B", Sources: []Source{{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/dir/bar.bin"}}}}}
+
+	hash1, err := HashDefinitions([]ArtifactDefinition{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash2, err := HashDefinitions([]ArtifactDefinition{b, a})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash1 != hash2 {
+		t.Errorf("HashDefinitions() depends on order: %s != %s", hash1, hash2)
+	}
+
+	b.Sources[0].Attributes.Paths = []string{"/dir/baz.bin"}
+	hash3, err := HashDefinitions([]ArtifactDefinition{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash1 == hash3 {
+		t.Error("HashDefinitions() did not change with definitions")
+	}
+
+	c := b
+	c.Sources = []Source{{Type: SourceType.Directory, Attributes: Attributes{Paths: []string{"/dir"}}}}
+	hash4, err := HashDefinitions([]ArtifactDefinition{a, b, c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash5, err := HashDefinitions([]ArtifactDefinition{c, a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash4 != hash5 {
+		t.Errorf("HashDefinitions() with duplicate names depends on order: %s != %s", hash4, hash5)
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	fsys := getInFS()
+	size, hashes, err := HashFile(fsys, "foo.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := fsys.Open("foo.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != int64(len(b)) || len(hashes.MD5) != 32 || len(hashes.SHA1) != 40 || len(hashes.SHA256) != 64 {
+		t.Errorf("HashFile() = %d, %#v", size, hashes)
+	}
+	if _, _, err := HashFile(fsys, "missing.bin"); err == nil {
+		t.Error("HashFile() of missing file succeeded")
+	}
+}
+
+func TestHashCopy(t *testing.T) {
+	buf := &bytes.Buffer{}
+	size, hashes, err := HashCopy(buf, strings.NewReader("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Hashes{
+		MD5:    "acbd18db4cc2f85cedef654fccc4a4d8",
+		SHA1:   "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33",
+		SHA256: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+	}
+	if size != 3 || hashes != want || buf.String() != "foo" {
+		t.Errorf("HashCopy() = %d, %#v, copied %q", size, hashes, buf.String())
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	currentVersionKey   = "HKEY_LOCAL_MACHINE/SOFTWARE/Microsoft/Windows/CurrentVersion"
+	currentVersionNTKey = "HKEY_LOCAL_MACHINE/SOFTWARE/Microsoft/Windows NT/CurrentVersion"
+	sessionManagerKey   = "Control/Session Manager/Environment"
+)
+
+// defaultEnvironment contains the documented defaults of Windows environment
+// variables. They are used if a variable is not set in the registry.
+var defaultEnvironment = map[string]string{
+	"SYSTEMDRIVE":        `C:`,
+	"SYSTEMROOT":         `%SystemDrive%\Windows`,
+	"WINDIR":             `%SystemRoot%`,
+	"PROGRAMFILES":       `%SystemDrive%\Program Files`,
+	"PROGRAMFILES(X86)":  `%SystemDrive%\Program Files (x86)`,
+	"PROGRAMW6432":       `%SystemDrive%\Program Files`,
+	"COMMONPROGRAMFILES": `%ProgramFiles%\Common Files`,
+	"PROGRAMDATA":        `%SystemDrive%\ProgramData`,
+	"ALLUSERSPROFILE":    `%ProgramData%`,
+	"PROFILESDIRECTORY":  `%SystemDrive%\Users`,
+	"PUBLIC":             `%ProfilesDirectory%\Public`,
+	"TEMP":               `%SystemRoot%\TEMP`,
+	"TMP":                `%SystemRoot%\TEMP`,
+	"COMSPEC":            `%SystemRoot%\system32\cmd.exe`,
+	"DRIVERDATA":         `%SystemRoot%\System32\Drivers\DriverData`,
+}
+
+// environmentValues are the registry values that define environment
+// variables. Keys starting with Control are relative to the current control
+// set.
+var environmentValues = []struct {
+	key, value, variable string
+}{
+	{currentVersionNTKey, "SystemRoot", "SYSTEMROOT"},
+	{currentVersionKey, "ProgramFilesDir", "PROGRAMFILES"},
+	{currentVersionKey, "ProgramFilesDir (x86)", "PROGRAMFILES(X86)"},
+	{currentVersionKey, "ProgramW6432Dir", "PROGRAMW6432"},
+	{currentVersionKey, "CommonFilesDir", "COMMONPROGRAMFILES"},
+	{profileListKey, "ProgramData", "PROGRAMDATA"},
+	{profileListKey, "ProfilesDirectory", "PROFILESDIRECTORY"},
+	{profileListKey, "Public", "PUBLIC"},
+	{profileListKey, "AllUsersProfile", "ALLUSERSPROFILE"},
+	{sessionManagerKey, "windir", "WINDIR"},
+	{sessionManagerKey, "TEMP", "TEMP"},
+	{sessionManagerKey, "TMP", "TMP"},
+	{sessionManagerKey, "ComSpec", "COMSPEC"},
+	{sessionManagerKey, "Path", "PATH"},
+	{sessionManagerKey, "DriverData", "DRIVERDATA"},
+	{sessionManagerKey, "OS", "OS"},
+	{sessionManagerKey, "PROCESSOR_ARCHITECTURE", "PROCESSOR_ARCHITECTURE"},
+}
+
+// environParameters maps environ_* parameters to environment variables if
+// their names differ.
+var environParameters = map[string]string{
+	"programfilesx86": "PROGRAMFILES(X86)",
+	"allusersappdata": "PROGRAMDATA",
+}
+
+// EnvironResolver resolves environ_* parameters like environ_systemroot of
+// Windows systems. Plain variable names like SystemRoot are resolved as well.
+type EnvironResolver struct {
+	// Environment contains the unexpanded variables by upper case name.
+	Environment map[string]string
+}
+
+// NewEnvironResolver creates an EnvironResolver that reads the environment
+// from a Windows registry. Variables that are not set in the registry are
+// set to their documented defaults.
+func NewEnvironResolver(registry fs.FS) *EnvironResolver {
+	environment := map[string]string{}
+	for name, value := range defaultEnvironment {
+		environment[name] = value
+	}
+
+	controlSet := currentControlSet(registry)
+	for _, environmentValue := range environmentValues {
+		key := environmentValue.key
+		if strings.HasPrefix(key, "Control/") {
+			key = path.Join(controlSet, key)
+		}
+		value, err := readRegistryValue(registry, key, environmentValue.value)
+		if err != nil || value == "" {
+			continue
+		}
+		environment[environmentValue.variable] = value
+	}
+
+	// Windows XP stores the name of the folder in the profiles directory
+	if profile := environment["ALLUSERSPROFILE"]; !strings.ContainsAny(profile, `\%`) {
+		environment["ALLUSERSPROFILE"] = `%ProfilesDirectory%\` + profile
+	}
+
+	r := &EnvironResolver{Environment: environment}
+	if systemRoot := r.Expand("%SystemRoot%"); len(systemRoot) > 1 && systemRoot[1] == ':' {
+		environment["SYSTEMDRIVE"] = systemRoot[:2]
+	}
+	return r
+}
+
+// currentControlSet returns the key of the current control set. Offline
+// registries do not contain CurrentControlSet, so it is looked up in Select.
+func currentControlSet(registry fs.FS) string {
+	const system = "HKEY_LOCAL_MACHINE/SYSTEM"
+	currentControlSet := path.Join(system, "CurrentControlSet")
+	if _, err := fs.Stat(registry, currentControlSet); err == nil {
+		return currentControlSet
+	}
+	current, err := readRegistryValue(registry, path.Join(system, "Select"), "Current")
+	if err != nil {
+		return currentControlSet
+	}
+	number, err := strconv.Atoi(strings.TrimSpace(current))
+	if err != nil {
+		return currentControlSet
+	}
+	return path.Join(system, fmt.Sprintf("ControlSet%03d", number))
+}
+
+// Resolve returns the expanded value of an environ_* parameter or a variable.
+func (r *EnvironResolver) Resolve(parameter string) ([]string, error) {
+	name := strings.ToLower(parameter)
+	if strings.HasPrefix(name, "environ_") {
+		name = strings.TrimPrefix(name, "environ_")
+		if variable, ok := environParameters[name]; ok {
+			name = variable
+		}
+	}
+	if _, ok := r.Environment[strings.ToUpper(name)]; !ok {
+		return nil, ErrUnknownParameter
+	}
+	return []string{r.Expand("%" + name + "%")}, nil
+}
+
+// Expand replaces all known %VARIABLE% references in s, including references
+// in the values of variables.
+func (r *EnvironResolver) Expand(s string) string {
+	return expandWindowsVariables(s, r.Environment)
+}
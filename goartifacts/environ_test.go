@@ -0,0 +1,101 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func getEnvironRegistry() fstest.MapFS {
+	environment := "HKEY_LOCAL_MACHINE/SYSTEM/ControlSet002/" + sessionManagerKey
+	return fstest.MapFS{
+		currentVersionNTKey + "/SystemRoot":            &fstest.MapFile{Data: []byte(`D:\WINDOWS`)},
+		currentVersionKey + "/ProgramFilesDir":         &fstest.MapFile{Data: []byte(`D:\Programs`)},
+		profileListKey + "/ProfilesDirectory":          &fstest.MapFile{Data: []byte(`%SystemDrive%\Documents and Settings`)},
+		profileListKey + "/AllUsersProfile":            &fstest.MapFile{Data: []byte(`All Users`)},
+		"HKEY_LOCAL_MACHINE/SYSTEM/Select/Current":     &fstest.MapFile{Data: []byte("2")},
+		"HKEY_LOCAL_MACHINE/SYSTEM/ControlSet001/keep": &fstest.MapFile{},
+		environment + "/windir":                        &fstest.MapFile{Data: []byte(`%SystemRoot%`)},
+		environment + "/TEMP":                          &fstest.MapFile{Data: []byte(`%SystemRoot%\Temp`)},
+		environment + "/Path":                          &fstest.MapFile{Data: []byte(`%SystemRoot%\system32;%UNKNOWN%`)},
+	}
+}
+
+func TestEnvironResolver_Resolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		registry  fstest.MapFS
+		parameter string
+		want      []string
+		wantErr   error
+	}{
+		{"systemdrive", getEnvironRegistry(), "environ_systemdrive", []string{`D:`}, nil},
+		{"systemroot", getEnvironRegistry(), "environ_systemroot", []string{`D:\WINDOWS`}, nil},
+		{"windir", getEnvironRegistry(), "environ_windir", []string{`D:\WINDOWS`}, nil},
+		{"temp", getEnvironRegistry(), "environ_temp", []string{`D:\WINDOWS\Temp`}, nil},
+		{"path", getEnvironRegistry(), "environ_path", []string{`D:\WINDOWS\system32;%UNKNOWN%`}, nil},
+		{"programfiles", getEnvironRegistry(), "environ_programfiles", []string{`D:\Programs`}, nil},
+		{"programfilesx86 default", getEnvironRegistry(), "environ_programfilesx86", []string{`D:\Program Files (x86)`}, nil},
+		{"allusersprofile xp", getEnvironRegistry(), "environ_allusersprofile", []string{`D:\Documents and Settings\All Users`}, nil},
+		{"variable", getEnvironRegistry(), "SystemRoot", []string{`D:\WINDOWS`}, nil},
+		{"unknown", getEnvironRegistry(), "environ_unknown", nil, ErrUnknownParameter},
+		{"users", getEnvironRegistry(), "users.homedir", nil, ErrUnknownParameter},
+		{"default systemroot", fstest.MapFS{}, "environ_systemroot", []string{`C:\Windows`}, nil},
+		{"default allusersprofile", fstest.MapFS{}, "environ_allusersprofile", []string{`C:\ProgramData`}, nil},
+		{"default allusersappdata", fstest.MapFS{}, "environ_allusersappdata", []string{`C:\ProgramData`}, nil},
+		{"default comspec", fstest.MapFS{}, "environ_comspec", []string{`C:\Windows\system32\cmd.exe`}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewEnvironResolver(tt.registry).Resolve(tt.parameter)
+			if err != tt.wantErr {
+				t.Errorf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_expandWindowsVariables(t *testing.T) {
+	variables := map[string]string{"A": "%b%", "B": "c", "LOOP": "%LOOP%x"}
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"nested", `%a%\%A%`, `c\c`},
+		{"unknown", `%unknown%\%b%`, `%unknown%\c`},
+		{"loop", `%loop%`, `%LOOP%xxxxxxxxxx`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandWindowsVariables(tt.in, variables); got != tt.want {
+				t.Errorf("expandWindowsVariables() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
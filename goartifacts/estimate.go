@@ -0,0 +1,223 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// Defaults for EstimateOptions.
+const (
+	DefaultSampleLimit = 10000
+	DefaultThroughput  = 50 << 20
+	DefaultItemCost    = time.Millisecond
+)
+
+// EstimateOptions configure Estimate.
+type EstimateOptions struct {
+	// OS is the operating system of the target, see RunOptions.
+	OS string
+	// Expand configures the expansion of the sources.
+	Expand ExpandOptions
+	// SampleLimit is the maximal number of files per source whose size is
+	// read. The size of larger sources is extrapolated from an evenly
+	// spaced sample. It does not limit the expansion: globs are always
+	// walked completely to count the items, which can take long for
+	// recursive globs. Defaults to DefaultSampleLimit.
+	SampleLimit int
+	// Throughput is the assumed number of bytes collected per second,
+	// defaults to DefaultThroughput.
+	Throughput int64
+	// ItemCost is the assumed duration to collect a single item, defaults
+	// to DefaultItemCost.
+	ItemCost time.Duration
+}
+
+// EstimateReport is the result of Estimate.
+type EstimateReport struct {
+	Items     int                `json:"items"`
+	Size      int64              `json:"size"`
+	Duration  time.Duration      `json:"duration"`
+	Unbounded bool               `json:"unbounded,omitempty"`
+	Unknown   []string           `json:"unknown,omitempty"`
+	Artifacts []ArtifactEstimate `json:"artifacts"`
+}
+
+// ArtifactEstimate is the estimation for a single artifact.
+type ArtifactEstimate struct {
+	Name    string           `json:"name"`
+	Items   int              `json:"items"`
+	Size    int64            `json:"size"`
+	Sources []SourceEstimate `json:"sources,omitempty"`
+}
+
+// SourceEstimate is the estimation for a single source. Only files add to
+// the size. Unbounded lists patterns with recursive globs.
+type SourceEstimate struct {
+	Index     int      `json:"index"`
+	Type      string   `json:"type"`
+	Items     int      `json:"items"`
+	Size      int64    `json:"size"`
+	Sampled   bool     `json:"sampled,omitempty"`
+	Unbounded []string `json:"unbounded,omitempty"`
+	Errors    int      `json:"errors,omitempty"`
+}
+
+// Estimate expands the selected artifacts through the file system and
+// registry of the target and estimates the number of items, the size and
+// the duration of their collection. No file content is read.
+func Estimate(artifactDefinitions []ArtifactDefinition, names []string, target ExpansionTarget, options EstimateOptions) *EstimateReport {
+	if options.SampleLimit <= 0 {
+		options.SampleLimit = DefaultSampleLimit
+	}
+	if options.Throughput <= 0 {
+		options.Throughput = DefaultThroughput
+	}
+	if options.ItemCost <= 0 {
+		options.ItemCost = DefaultItemCost
+	}
+	operatingSystem := RunOptions{OS: options.OS}.os()
+
+	report := &EstimateReport{Artifacts: []ArtifactEstimate{}}
+	selected, unknown := selectArtifacts(names, artifactDefinitions, operatingSystem)
+	report.Unknown = unknown
+	for _, artifactDefinition := range selected {
+		artifact := ArtifactEstimate{Name: artifactDefinition.Name}
+		expandOptions := options.Expand.ForDefinition(artifactDefinition)
+		for i, source := range artifactDefinition.Sources {
+			if source.Type == SourceType.ArtifactGroup || !IsOSArtifactDefinition(operatingSystem, source.SupportedOs) {
+				continue
+			}
+			estimate := estimateSource(source, target, expandOptions, options.SampleLimit)
+			estimate.Index = i
+			artifact.Items += estimate.Items
+			artifact.Size += estimate.Size
+			if len(estimate.Unbounded) > 0 {
+				report.Unbounded = true
+			}
+			artifact.Sources = append(artifact.Sources, estimate)
+		}
+		report.Items += artifact.Items
+		report.Size += artifact.Size
+		report.Artifacts = append(report.Artifacts, artifact)
+	}
+
+	report.Duration = time.Duration(float64(report.Size)/float64(options.Throughput)*float64(time.Second)) +
+		time.Duration(report.Items)*options.ItemCost
+	return report
+}
+
+func estimateSource(source Source, target ExpansionTarget, options ExpandOptions, sampleLimit int) SourceEstimate {
+	estimate := SourceEstimate{Type: source.Type}
+	switch source.Type {
+	case SourceType.Command, SourceType.Wmi:
+		estimate.Items = 1
+		return estimate
+	case SourceType.File, SourceType.Directory, SourceType.Path:
+		for _, p := range source.Attributes.Paths {
+			if strings.Contains(p, "**") {
+				estimate.Unbounded = append(estimate.Unbounded, p)
+			}
+		}
+	}
+
+	expansion := ExpandSourceWithOptions(source, target, options)
+	estimate.Items = len(expansion.Paths)
+	if source.Type != SourceType.File || estimate.Items == 0 {
+		return estimate
+	}
+
+	step := 1
+	if estimate.Items > sampleLimit {
+		step = (estimate.Items + sampleLimit - 1) / sampleLimit
+		estimate.Sampled = true
+	}
+	fsys := target.FS()
+	sampled := 0
+	for i := 0; i < estimate.Items; i += step {
+		sampled++
+		info, err := fs.Stat(fsys, expansion.Paths[i].Path)
+		if err != nil {
+			estimate.Errors++
+			continue
+		}
+		if !info.IsDir() {
+			estimate.Size += info.Size()
+		}
+	}
+	if estimate.Sampled {
+		estimate.Size = estimate.Size * int64(estimate.Items) / int64(sampled)
+	}
+	return estimate
+}
+
+// Budget limits a collection. Zero values are not limited.
+type Budget struct {
+	MaxItems       int
+	MaxSize        int64
+	MaxDuration    time.Duration
+	AllowUnbounded bool
+	AllowUnknown   bool
+}
+
+// BudgetError lists the limits of a budget an estimation exceeds.
+type BudgetError struct {
+	Violations []string
+}
+
+func (e *BudgetError) Error() string {
+	return "collection exceeds budget: " + strings.Join(e.Violations, ", ")
+}
+
+// Check compares the estimation with a budget and returns a *BudgetError if
+// it is exceeded.
+func (r *EstimateReport) Check(budget Budget) error {
+	var violations []string
+	if budget.MaxItems > 0 && r.Items > budget.MaxItems {
+		violations = append(violations, fmt.Sprintf("%d items, budget %d", r.Items, budget.MaxItems))
+	}
+	if budget.MaxSize > 0 && r.Size > budget.MaxSize {
+		violations = append(violations, fmt.Sprintf("%d bytes, budget %d", r.Size, budget.MaxSize))
+	}
+	if budget.MaxDuration > 0 && r.Duration > budget.MaxDuration {
+		violations = append(violations, fmt.Sprintf("%s, budget %s", r.Duration, budget.MaxDuration))
+	}
+	if r.Unbounded && !budget.AllowUnbounded {
+		for _, artifact := range r.Artifacts {
+			for _, source := range artifact.Sources {
+				for _, pattern := range source.Unbounded {
+					violations = append(violations, fmt.Sprintf("unbounded glob %s in %s", pattern, artifact.Name))
+				}
+			}
+		}
+	}
+	if len(r.Unknown) > 0 && !budget.AllowUnknown {
+		violations = append(violations, "unknown artifacts "+strings.Join(r.Unknown, ", "))
+	}
+	if len(violations) > 0 {
+		return &BudgetError{Violations: violations}
+	}
+	return nil
+}
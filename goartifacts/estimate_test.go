@@ -0,0 +1,66 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimate(t *testing.T) {
+	definitions := []ArtifactDefinition{
+		{Name: "Files", Sources: []Source{
+			{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/foo.bin", "/dir/*.bin"}}},
+			{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/dir/**/foo.bin"}}},
+			{Type: SourceType.Directory, Attributes: Attributes{Paths: []string{"/dir"}}},
+			{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/foo.bin"}}, SupportedOs: []string{"Windows"}},
+		}},
+		{Name: "Command", Sources: []Source{{Type: SourceType.Command, Attributes: Attributes{Cmd: "id"}}}},
+	}
+	collector := &TestCollector{fs: getInFS()}
+
+	report := Estimate(definitions, []string{"Files", "Command", "Unknown"}, collector, EstimateOptions{OS: "Linux", Throughput: 4, ItemCost: time.Second})
+	if report.Items != 9 || report.Size != 28 || !report.Unbounded {
+		t.Errorf("Estimate() = %d items, %d bytes, unbounded %v", report.Items, report.Size, report.Unbounded)
+	}
+	if report.Duration != 16*time.Second {
+		t.Errorf("Estimate() duration = %s", report.Duration)
+	}
+	files := report.Artifacts[0]
+	if len(files.Sources) != 3 || files.Sources[0].Size != 12 || files.Sources[1].Unbounded[0] != "/dir/**/foo.bin" || files.Sources[2].Items != 1 {
+		t.Errorf("Estimate() sources = %#v", files.Sources)
+	}
+
+	sampled := Estimate(definitions, []string{"Files"}, collector, EstimateOptions{OS: "Linux", SampleLimit: 2})
+	if !sampled.Artifacts[0].Sources[1].Sampled || sampled.Artifacts[0].Sources[1].Size != 16 {
+		t.Errorf("Estimate() sampled = %#v", sampled.Artifacts[0].Sources[1])
+	}
+
+	if err := report.Check(Budget{AllowUnbounded: true, AllowUnknown: true}); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+	err := report.Check(Budget{MaxItems: 5, MaxSize: 100, MaxDuration: time.Second})
+	budgetErr, ok := err.(*BudgetError)
+	if !ok || len(budgetErr.Violations) != 4 {
+		t.Errorf("Check() error = %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/forensicanalysis/fsdoublestar"
+)
+
+// regexPrefix marks exclusion patterns that are regular expressions.
+const regexPrefix = "re:"
+
+// ExcludedPath is a path or key that was removed by an exclusion pattern.
+type ExcludedPath struct {
+	Path    string `json:"path"`
+	Pattern string `json:"pattern"`
+}
+
+// exclusion is a compiled exclusion pattern.
+type exclusion struct {
+	pattern string
+	glob    string
+	regex   *regexp.Regexp
+}
+
+// compileExclusions compiles a list of exclusion patterns. Patterns are
+// globs, unless they are prefixed with "re:".
+func compileExclusions(patterns []string) ([]exclusion, error) {
+	var exclusions []exclusion
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, regexPrefix) {
+			regex, err := regexp.Compile(pattern[len(regexPrefix):])
+			if err != nil {
+				return nil, fmt.Errorf("invalid exclusion %s: %s", pattern, err)
+			}
+			exclusions = append(exclusions, exclusion{pattern: pattern, regex: regex})
+			continue
+		}
+
+		glob := strings.ToLower(normalizePattern(pattern))
+		for _, element := range strings.Split(glob, "/") {
+			if _, err := path.Match(element, ""); err != nil {
+				return nil, fmt.Errorf("invalid exclusion %s: %s", pattern, err)
+			}
+		}
+		exclusions = append(exclusions, exclusion{pattern: pattern, glob: glob})
+	}
+	return exclusions, nil
+}
+
+// normalizePattern converts Windows and Unix paths into paths in the form
+// used by the file systems, e.g. C:\pagefile.sys becomes C/pagefile.sys.
+func normalizePattern(pattern string) string {
+	pattern = strings.Replace(pattern, `\`, "/", -1)
+	pattern = strings.TrimLeft(pattern, "/")
+	if len(pattern) > 1 && pattern[1] == ':' && isLetter(pattern[0]) {
+		pattern = pattern[:1] + pattern[2:]
+	}
+	return pattern
+}
+
+func (e exclusion) match(name string) bool {
+	if e.regex != nil {
+		return e.regex.MatchString(name)
+	}
+	match, _ := fsdoublestar.Match(e.glob, strings.ToLower(name))
+	return match
+}
+
+// isExcluded checks if name or one of its parents is excluded. Excluded
+// paths are recorded once.
+func (e *expansionFS) isExcluded(name string) bool {
+	if len(e.exclusions) == 0 || name == "." || name == "" {
+		return false
+	}
+	if pattern, ok := e.excludedBy[name]; ok {
+		return pattern != ""
+	}
+
+	if e.isExcluded(path.Dir(name)) {
+		e.excludedBy[name] = e.excludedBy[path.Dir(name)]
+		return true
+	}
+
+	for _, exclusion := range e.exclusions {
+		if exclusion.match(name) {
+			e.excludedBy[name] = exclusion.pattern
+			e.excluded = append(e.excluded, ExcludedPath{Path: name, Pattern: exclusion.pattern})
+			return true
+		}
+	}
+	e.excludedBy[name] = ""
+	return false
+}
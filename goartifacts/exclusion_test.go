@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"io/fs"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type countingFS struct {
+	fs.FS
+	readDirs map[string]int
+}
+
+func (c *countingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	c.readDirs[name]++
+	return fs.ReadDir(c.FS, name)
+}
+
+func TestExpandSourceWithOptions_Exclude(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		exclude      []string
+		want         []string
+		wantExcluded []ExcludedPath
+		wantPruned   []string
+	}{
+		{"no exclusion", "dir/*/*/foo.bin", nil, []string{"dir/a/a/foo.bin", "dir/a/b/foo.bin", "dir/b/a/foo.bin", "dir/b/b/foo.bin"}, nil, nil},
+		{"exclude directory", "dir/**", []string{"/dir/a"}, []string{"dir/b", "dir/b/a", "dir/b/a/foo.bin", "dir/b/b", "dir/b/b/foo.bin", "dir/bar.bin", "dir/baz.bin"}, []ExcludedPath{{"dir/a", "/dir/a"}}, []string{"dir/a"}},
+		{"exclude windows path", "dir/*.bin", []string{`\DIR\bar.bin`}, []string{"dir/baz.bin"}, []ExcludedPath{{"dir/bar.bin", `\DIR\bar.bin`}}, nil},
+		{"exclude double star", "dir/*/*/foo.bin", []string{"**/b/foo.bin"}, []string{"dir/a/a/foo.bin", "dir/b/a/foo.bin"}, []ExcludedPath{{"dir/a/b/foo.bin", "**/b/foo.bin"}, {"dir/b/b/foo.bin", "**/b/foo.bin"}}, nil},
+		{"exclude literal path", "dir/bar.bin", []string{"dir/bar.bin"}, nil, []ExcludedPath{{"dir/bar.bin", "dir/bar.bin"}}, nil},
+		{"exclude regex", "dir/*/*/foo.bin", []string{`re:^dir/b/.$`}, []string{"dir/a/a/foo.bin", "dir/a/b/foo.bin"}, []ExcludedPath{{"dir/b/a", `re:^dir/b/.$`}, {"dir/b/b", `re:^dir/b/.$`}}, []string{"dir/b/a", "dir/b/b"}},
+		{"invalid regex", "dir/*.bin", []string{`re:(`}, nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := &countingFS{FS: getInFS(), readDirs: map[string]int{}}
+			collector := &TestCollector{fs: fsys}
+			source := Source{Type: SourceType.File, Attributes: Attributes{Paths: []string{tt.path}}}
+			got := ExpandSourceWithOptions(source, collector, ExpandOptions{Exclude: tt.exclude})
+
+			paths := got.Source.Attributes.Paths
+			sort.Strings(paths)
+			if !reflect.DeepEqual(paths, tt.want) {
+				t.Errorf("ExpandSourceWithOptions() = %v, want %v", paths, tt.want)
+			}
+			if !reflect.DeepEqual(got.Excluded, tt.wantExcluded) {
+				t.Errorf("ExpandSourceWithOptions() excluded = %v, want %v", got.Excluded, tt.wantExcluded)
+			}
+			for _, pruned := range tt.wantPruned {
+				if fsys.readDirs[pruned] > 0 {
+					t.Errorf("ExpandSourceWithOptions() traversed %s", pruned)
+				}
+			}
+		})
+	}
+}
+
+func TestExpandOptions_ForDefinition(t *testing.T) {
+	options := ExpandOptions{Exclude: []string{"a"}}
+	artifactDefinition := ArtifactDefinition{Extensions: Extensions{Exclude: []string{"b"}}}
+
+	got := options.ForDefinition(artifactDefinition)
+	if !reflect.DeepEqual(got.Exclude, []string{"a", "b"}) {
+		t.Errorf("ForDefinition() = %v, want %v", got.Exclude, []string{"a", "b"})
+	}
+	if !reflect.DeepEqual(options.Exclude, []string{"a"}) {
+		t.Errorf("ForDefinition() modified options %v", options.Exclude)
+	}
+	if err := (ExpandOptions{Exclude: []string{"re:("}}).Validate(); err == nil {
+		t.Error("Validate() expected error")
+	}
+}
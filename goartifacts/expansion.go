@@ -36,17 +36,116 @@ const windows = "windows"
 
 // ExpandSource expands a single artifact definition source by expanding its
 // paths or keys.
-func ExpandSource(source Source, collector ArtifactCollector) Source {
+func ExpandSource(source Source, collector ExpansionTarget) Source {
+	return expandSource(source, collector, nil).Source
+}
+
+// ExpandOptions configure the expansion of artifact definition sources.
+type ExpandOptions struct {
+	// Symlinks defines how symbolic links and junctions are handled.
+	Symlinks SymlinkPolicy
+	// Exclude contains patterns for paths and registry keys that are never
+	// expanded or traversed. Patterns are case insensitive globs like
+	// `C:\pagefile.sys` or `/home/*/.cache`, or regular expressions that
+	// are matched against the expanded path if prefixed with "re:".
+	Exclude []string
+}
+
+// Validate checks if all exclusion patterns are valid.
+func (o ExpandOptions) Validate() error {
+	_, err := compileExclusions(o.Exclude)
+	return err
+}
+
+func (o ExpandOptions) isZero() bool {
+	return o.Symlinks == FollowSymlinks && len(o.Exclude) == 0
+}
+
+// ForDefinition returns a copy of the options that additionally contains
+// the exclusions of the artifact definition.
+func (o ExpandOptions) ForDefinition(artifactDefinition ArtifactDefinition) ExpandOptions {
+	if len(artifactDefinition.Extensions.Exclude) == 0 {
+		return o
+	}
+	o.Exclude = appendString(o.Exclude, artifactDefinition.Extensions.Exclude...)
+	return o
+}
+
+// ExpandedPath is a single path or registry key an expanded source refers to.
+type ExpandedPath struct {
+	Path string `json:"path"`
+	// Links lists all links that were followed to reach the path, including
+	// the path itself if it is a followed link.
+	Links []string `json:"links,omitempty"`
+}
+
+// Expansion is the result of ExpandSourceWithOptions.
+type Expansion struct {
+	// Source is the expanded source as returned by ExpandSource.
+	Source Source `json:"source"`
+	// Paths contains all expanded paths or keys with annotations.
+	Paths []ExpandedPath `json:"paths,omitempty"`
+	// Excluded contains all paths or keys that were removed by exclusions.
+	Excluded []ExcludedPath `json:"excluded,omitempty"`
+}
+
+// ExpandSourceWithOptions expands a single artifact definition source like
+// ExpandSource, but applies the given options and annotates the results. If
+// the options are invalid nothing is expanded.
+func ExpandSourceWithOptions(source Source, collector ExpansionTarget, options ExpandOptions) Expansion {
+	return expandSource(source, collector, &options)
+}
+
+func expandSource(source Source, collector ExpansionTarget, options *ExpandOptions) Expansion { // nolint:gocyclo
+	if source.Expanded {
+		return expandedSource(source)
+	}
+
 	replacer := strings.NewReplacer("\\", "/", "/", "\\")
+	expansion := Expansion{}
+
+	var fsys, registry fs.FS = collector.FS(), collector.Registry()
+	var wrapped *expansionFS
+	if options != nil {
+		exclusions, err := compileExclusions(options.Exclude)
+		if err != nil {
+			log.Println(err)
+			source.Attributes.Paths, source.Attributes.Keys, source.Attributes.KeyValuePairs = nil, nil, nil
+			expansion.Source = source
+			return expansion
+		}
+		switch source.Type {
+		case SourceType.File, SourceType.Directory, SourceType.Path:
+			wrapped = newExpansionFS(fsys, *options, exclusions, true)
+			fsys = wrapped
+		default:
+			wrapped = newExpansionFS(registry, *options, exclusions, false)
+			registry = wrapped
+		}
+	}
+	annotate := func(paths []string) {
+		for _, p := range paths {
+			expandedPath := ExpandedPath{Path: p}
+			if wrapped != nil {
+				expandedPath.Links = wrapped.followedLinks(p)
+			}
+			expansion.Paths = append(expansion.Paths, expandedPath)
+		}
+		if wrapped != nil {
+			expansion.Excluded = wrapped.excluded
+		}
+	}
+
 	switch source.Type {
 	case SourceType.File, SourceType.Directory, SourceType.Path:
+
 		// expand paths
 		var expandedPaths []string
 		for _, path := range source.Attributes.Paths {
 			if source.Attributes.Separator == "\\" {
 				path = strings.Replace(path, "\\", "/", -1)
 			}
-			paths, err := expandPath(collector.FS(), path, collector.Prefixes(), collector)
+			paths, err := expandPath(fsys, path, collector.Prefixes(), collector)
 			if err != nil {
 				log.Println(err)
 				continue
@@ -54,12 +153,13 @@ func ExpandSource(source Source, collector ArtifactCollector) Source {
 			expandedPaths = append(expandedPaths, paths...)
 		}
 		source.Attributes.Paths = expandedPaths
+		annotate(expandedPaths)
 	case SourceType.RegistryKey:
 		// expand keys
 		var expandKeys []string
 		for _, key := range source.Attributes.Keys {
 			key = "/" + replacer.Replace(key)
-			keys, err := expandKey(key, collector)
+			keys, err := expandKeyFS(registry, key, collector)
 			if err != nil {
 				log.Println(err)
 				continue
@@ -67,12 +167,14 @@ func ExpandSource(source Source, collector ArtifactCollector) Source {
 			expandKeys = append(expandKeys, keys...)
 		}
 		source.Attributes.Keys = expandKeys
+		annotate(expandKeys)
 	case SourceType.RegistryValue:
 		// expand key value pairs
 		var expandKeyValuePairs []KeyValuePair
+		var expandKeys []string
 		for _, keyValuePair := range source.Attributes.KeyValuePairs {
 			key := "/" + replacer.Replace(keyValuePair.Key)
-			keys, err := expandKey(key, collector)
+			keys, err := expandKeyFS(registry, key, collector)
 			if err != nil {
 				log.Println(err)
 				continue
@@ -80,51 +182,35 @@ func ExpandSource(source Source, collector ArtifactCollector) Source {
 			for _, expandKey := range keys {
 				expandKeyValuePairs = append(expandKeyValuePairs, KeyValuePair{Key: expandKey, Value: keyValuePair.Value})
 			}
+			expandKeys = append(expandKeys, keys...)
 		}
 		source.Attributes.KeyValuePairs = expandKeyValuePairs
+		annotate(expandKeys)
 	}
-	return source
+	expansion.Source = source
+	return expansion
 }
 
-func expandArtifactGroup(names []string, definitions map[string]ArtifactDefinition) map[string]ArtifactDefinition {
-	selected := map[string]ArtifactDefinition{}
-	for _, name := range names {
-		artifact, ok := definitions[name]
-		if !ok {
-			log.Printf("Artifact Definition %s not found", name)
-			continue
-		}
-
-		if !IsOSArtifactDefinition(runtime.GOOS, artifact.SupportedOs) {
-			continue
-		}
-
-		onlyGroup := true
-		for _, source := range artifact.Sources {
-			if source.Type == SourceType.ArtifactGroup {
-				if IsOSArtifactDefinition(runtime.GOOS, source.SupportedOs) {
-					for subName, subArtifact := range expandArtifactGroup(source.Attributes.Names, definitions) {
-						selected[subName] = subArtifact
-					}
-				}
-			} else {
-				onlyGroup = false
-			}
-		}
-		if !onlyGroup {
-			var sources []Source
-			for _, source := range artifact.Sources {
-				if IsOSArtifactDefinition(runtime.GOOS, source.SupportedOs) {
-					sources = append(sources, source)
-				}
-			}
-			artifact.Sources = sources
-
-			selected[artifact.Name] = artifact
+// expandedSource returns the expansion of an already expanded source. Paths
+// and keys are not resolved or globbed again, as they may contain % or glob
+// characters.
+func expandedSource(source Source) Expansion {
+	expansion := Expansion{Source: source}
+	var paths []string
+	switch source.Type {
+	case SourceType.File, SourceType.Directory, SourceType.Path:
+		paths = source.Attributes.Paths
+	case SourceType.RegistryKey:
+		paths = source.Attributes.Keys
+	case SourceType.RegistryValue:
+		for _, keyValuePair := range source.Attributes.KeyValuePairs {
+			paths = append(paths, keyValuePair.Key)
 		}
 	}
-
-	return selected
+	for _, p := range paths {
+		expansion.Paths = append(expansion.Paths, ExpandedPath{Path: p})
+	}
+	return expansion
 }
 
 func isLetter(c byte) bool {
@@ -173,7 +259,7 @@ func toForensicPath(name string, prefixes []string) ([]string, error) { // nolin
 	return []string{name}, nil
 }
 
-func expandPath(fs fs.FS, syspath string, prefixes []string, collector ArtifactCollector) ([]string, error) {
+func expandPath(fs fs.FS, syspath string, prefixes []string, collector ExpansionTarget) ([]string, error) {
 	// expand vars
 	variablePaths, err := recursiveResolve(syspath, collector)
 	if err != nil {
@@ -189,6 +275,12 @@ func expandPath(fs fs.FS, syspath string, prefixes []string, collector ArtifactC
 		if err != nil {
 			return nil, err
 		}
+		if mapper, ok := collector.(DriveMapper); ok {
+			drives := mapper.Drives()
+			for i := range forensicPaths {
+				forensicPaths[i] = mapDrive(forensicPaths[i], drives)
+			}
+		}
 		partitionPaths = append(partitionPaths, forensicPaths...)
 	}
 
@@ -217,14 +309,18 @@ func expandPath(fs fs.FS, syspath string, prefixes []string, collector ArtifactC
 	return uniquePaths, nil
 }
 
-func expandKey(path string, collector ArtifactCollector) ([]string, error) {
+func expandKey(path string, collector ExpansionTarget) ([]string, error) {
+	return expandKeyFS(collector.Registry(), path, collector)
+}
+
+func expandKeyFS(registry fs.FS, path string, collector ExpansionTarget) ([]string, error) {
 	if runtime.GOOS == windows {
-		return expandPath(collector.Registry(), path, nil, collector)
+		return expandPath(registry, path, nil, collector)
 	}
 	return []string{}, nil
 }
 
-func recursiveResolve(s string, collector ArtifactCollector) ([]string, error) {
+func recursiveResolve(s string, collector ExpansionTarget) ([]string, error) {
 	var re = regexp.MustCompile(`%?%(.*?)%?%`)
 	matches := re.FindAllStringSubmatch(s, -1)
 
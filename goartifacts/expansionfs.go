@@ -0,0 +1,126 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"io/fs"
+	"path"
+)
+
+// expansionFS wraps a file system and applies the expansion options. Paths
+// that are excluded or cannot be reached with the symlink policy do not
+// exist in the expansionFS, so they are never traversed.
+type expansionFS struct {
+	fsys       fs.FS
+	options    ExpandOptions
+	links      bool
+	exclusions []exclusion
+
+	entries  map[string]fs.DirEntry
+	listed   map[string]bool
+	resolved map[string]*resolvedPath
+
+	excludedBy map[string]string
+	excluded   []ExcludedPath
+}
+
+func newExpansionFS(fsys fs.FS, options ExpandOptions, exclusions []exclusion, links bool) *expansionFS {
+	return &expansionFS{
+		fsys:       fsys,
+		options:    options,
+		links:      links,
+		exclusions: exclusions,
+		entries:    map[string]fs.DirEntry{},
+		listed:     map[string]bool{},
+		resolved:   map[string]*resolvedPath{},
+		excludedBy: map[string]string{},
+	}
+}
+
+// Open opens the named file, if it can be reached with the current options.
+func (e *expansionFS) Open(name string) (fs.File, error) {
+	if !e.reachable(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return e.fsys.Open(name)
+}
+
+// Stat returns the fs.FileInfo for the named file. Links that are not
+// followed are returned as is.
+func (e *expansionFS) Stat(name string) (fs.FileInfo, error) {
+	if e.isExcluded(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	if e.links {
+		r := e.resolve(name, 0)
+		switch {
+		case r.blocked:
+			return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+		case r.stopped != nil:
+			return r.stopped, nil
+		}
+	}
+	return fs.Stat(e.fsys, name)
+}
+
+// ReadDir lists the named directory, if it can be reached with the current
+// options. Excluded entries are removed.
+func (e *expansionFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !e.reachable(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := e.readDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []fs.DirEntry
+	for _, entry := range entries {
+		if !e.isExcluded(path.Join(name, entry.Name())) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
+
+func (e *expansionFS) reachable(name string) bool {
+	if e.isExcluded(name) {
+		return false
+	}
+	if e.links {
+		r := e.resolve(name, 0)
+		return !r.blocked && r.stopped == nil
+	}
+	return true
+}
+
+func (e *expansionFS) readDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(e.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	e.listed[name] = true
+	for _, entry := range entries {
+		e.entries[path.Join(name, entry.Name())] = entry
+	}
+	return entries, nil
+}
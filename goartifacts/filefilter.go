@@ -0,0 +1,250 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// TimeWindow is a time range. Zero values are not limited.
+type TimeWindow struct {
+	After  time.Time `yaml:"after,omitempty"`
+	Before time.Time `yaml:"before,omitempty"`
+}
+
+func (w TimeWindow) isZero() bool {
+	return w.After.IsZero() && w.Before.IsZero()
+}
+
+func (w TimeWindow) check(name string, t time.Time) string {
+	switch {
+	case !w.After.IsZero() && t.Before(w.After):
+		return fmt.Sprintf("%s %s before %s", name, t.UTC().Format(time.RFC3339), w.After.UTC().Format(time.RFC3339))
+	case !w.Before.IsZero() && t.After(w.Before):
+		return fmt.Sprintf("%s %s after %s", name, t.UTC().Format(time.RFC3339), w.Before.UTC().Format(time.RFC3339))
+	}
+	return ""
+}
+
+// Magic is a byte sequence at an offset that identifies a file type.
+type Magic struct {
+	Offset int
+	Bytes  []byte
+}
+
+// FileTypes maps file type names to their magic bytes. It can be extended
+// with additional types.
+var FileTypes = map[string][]Magic{
+	"elf":      {{0, []byte("\x7fELF")}},
+	"esedb":    {{4, []byte("\xef\xcd\xab\x89")}},
+	"evt":      {{4, []byte("LfLe")}},
+	"evtx":     {{0, []byte("ElfFile\x00")}},
+	"gzip":     {{0, []byte("\x1f\x8b")}},
+	"jpeg":     {{0, []byte("\xff\xd8\xff")}},
+	"lnk":      {{0, []byte("\x4c\x00\x00\x00\x01\x14\x02\x00")}},
+	"macho":    {{0, []byte("\xfe\xed\xfa\xce")}, {0, []byte("\xfe\xed\xfa\xcf")}, {0, []byte("\xce\xfa\xed\xfe")}, {0, []byte("\xcf\xfa\xed\xfe")}},
+	"pdf":      {{0, []byte("%PDF-")}},
+	"pe":       {{0, []byte("MZ")}},
+	"plist":    {{0, []byte("bplist00")}},
+	"png":      {{0, []byte("\x89PNG\r\n\x1a\n")}},
+	"prefetch": {{4, []byte("SCCA")}, {0, []byte("MAM\x04")}},
+	"registry": {{0, []byte("regf")}},
+	"sqlite":   {{0, []byte("SQLite format 3\x00")}},
+	"vhd":      {{0, []byte("conectix")}},
+	"vhdx":     {{0, []byte("vhdxfile")}},
+	"vmdk":     {{0, []byte("KDMV")}},
+	"zip":      {{0, []byte("PK\x03\x04")}},
+}
+
+// FilteredItem is an item that was removed by a FileFilter.
+type FilteredItem struct {
+	Item   string `json:"item"`
+	Reason string `json:"reason"`
+}
+
+// FileFilter selects files by time, size and type. All conditions must
+// match for a file to be collected.
+//
+// Modification times are taken from fs.FileInfo. Change and birth times
+// are read from the attributes "changed" and "created" that the file
+// systems of fslib return in Sys(). Files without these times are not
+// filtered by the Changed and Created windows.
+type FileFilter struct {
+	Modified TimeWindow `yaml:"modified,omitempty"`
+	Changed  TimeWindow `yaml:"changed,omitempty"`
+	Created  TimeWindow `yaml:"created,omitempty"`
+
+	// MinSize and MaxSize limit the file size, zero values are not limited.
+	MinSize int64 `yaml:"min_size,omitempty"`
+	MaxSize int64 `yaml:"max_size,omitempty"`
+
+	// Types only selects files of the listed types, ExcludeTypes removes
+	// files of the listed types. Types are names from FileTypes.
+	Types        []string `yaml:"types,omitempty"`
+	ExcludeTypes []string `yaml:"exclude_types,omitempty"`
+}
+
+// Validate checks if all file types are known.
+func (f *FileFilter) Validate() error {
+	for _, fileType := range appendString(f.Types, f.ExcludeTypes...) {
+		if _, ok := FileTypes[fileType]; !ok {
+			return fmt.Errorf("unknown file type %s", fileType)
+		}
+	}
+	return nil
+}
+
+// Check returns why a file is removed by the filter or an empty string if
+// it is kept.
+func (f *FileFilter) Check(fsys fs.FS, name string) (string, error) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "is a directory", nil
+	}
+
+	switch {
+	case f.MinSize > 0 && info.Size() < f.MinSize:
+		return fmt.Sprintf("size %d below %d", info.Size(), f.MinSize), nil
+	case f.MaxSize > 0 && info.Size() > f.MaxSize:
+		return fmt.Sprintf("size %d above %d", info.Size(), f.MaxSize), nil
+	}
+
+	if reason := f.Modified.check("modified", info.ModTime()); reason != "" {
+		return reason, nil
+	}
+	if !f.Changed.isZero() || !f.Created.isZero() {
+		if changed, ok := sysTime(info, "changed"); ok {
+			if reason := f.Changed.check("changed", changed); reason != "" {
+				return reason, nil
+			}
+		}
+		if created, ok := sysTime(info, "created"); ok {
+			if reason := f.Created.check("created", created); reason != "" {
+				return reason, nil
+			}
+		}
+	}
+
+	if len(f.Types) > 0 || len(f.ExcludeTypes) > 0 {
+		fileType, err := DetectFileType(fsys, name)
+		if err != nil {
+			return "", err
+		}
+		if len(f.Types) > 0 && !containsString(f.Types, fileType) {
+			if fileType == "" {
+				fileType = "unknown"
+			}
+			return fmt.Sprintf("file type %s not selected", fileType), nil
+		}
+		if containsString(f.ExcludeTypes, fileType) {
+			return fmt.Sprintf("file type %s excluded", fileType), nil
+		}
+	}
+	return "", nil
+}
+
+// filter returns the items that pass the filter and the removed items. Items
+// that cannot be checked are kept, so the collector can report the error.
+func (f *FileFilter) filter(fsys fs.FS, items []string) (remaining []string, filtered []FilteredItem) {
+	for _, item := range items {
+		reason, err := f.Check(fsys, item)
+		if err != nil || reason == "" {
+			remaining = append(remaining, item)
+			continue
+		}
+		filtered = append(filtered, FilteredItem{Item: item, Reason: reason})
+	}
+	return remaining, filtered
+}
+
+// DetectFileType returns the name of the first type in FileTypes whose magic
+// bytes match the file, or an empty string if none matches.
+func DetectFileType(fsys fs.FS, name string) (string, error) {
+	size := 0
+	for _, magics := range FileTypes {
+		for _, magic := range magics {
+			if end := magic.Offset + len(magic.Bytes); end > size {
+				size = end
+			}
+		}
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	header := make([]byte, size)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	header = header[:n]
+
+	var names []string
+	for fileType := range FileTypes {
+		names = append(names, fileType)
+	}
+	sort.Strings(names)
+	for _, fileType := range names {
+		for _, magic := range FileTypes[fileType] {
+			end := magic.Offset + len(magic.Bytes)
+			if end <= len(header) && bytes.Equal(header[magic.Offset:end], magic.Bytes) {
+				return fileType, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// sysTime reads a time attribute from the Sys() map of fslib file systems.
+func sysTime(info fs.FileInfo, name string) (time.Time, bool) {
+	attributes, ok := info.Sys().(map[string]interface{})
+	if !ok {
+		return time.Time{}, false
+	}
+	switch value := attributes[name].(type) {
+	case time.Time:
+		return value, true
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
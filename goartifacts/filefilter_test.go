@@ -0,0 +1,98 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFileFilter(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+	fsys := fstest.MapFS{
+		"old.txt":   &fstest.MapFile{Data: []byte("old"), ModTime: day(1)},
+		"new.txt":   &fstest.MapFile{Data: []byte("new"), ModTime: day(10)},
+		"large.txt": &fstest.MapFile{Data: make([]byte, 100), ModTime: day(10)},
+		"disk.vhdx": &fstest.MapFile{Data: []byte("vhdxfile..."), ModTime: day(10)},
+		"created.txt": &fstest.MapFile{Data: []byte("txt"), ModTime: day(10), Sys: map[string]interface{}{
+			"created": day(2).Format(time.RFC3339Nano),
+		}},
+		"app.exe": &fstest.MapFile{Data: []byte("MZ\x90\x00"), ModTime: day(10)},
+	}
+	filter := &FileFilter{
+		Modified:     TimeWindow{After: day(5)},
+		Created:      TimeWindow{After: day(5)},
+		MaxSize:      50,
+		ExcludeTypes: []string{"vhdx"},
+	}
+	if err := filter.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&FileFilter{Types: []string{"foo"}}).Validate(); err == nil {
+		t.Error("Validate() accepted unknown type")
+	}
+
+	definitions := []ArtifactDefinition{{Name: "Files", Sources: []Source{
+		{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/*"}}},
+		{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/old.txt"}}},
+		{Type: SourceType.Path, Attributes: Attributes{Paths: []string{"/old.txt"}}},
+	}}}
+	collector := &TestCollector{fs: fsys}
+	report := Run(definitions, []string{"Files"}, collector, RunOptions{OS: "Linux", FileFilter: filter})
+
+	sources := report.Artifacts[0].Sources
+	wantFiltered := []FilteredItem{
+		{"created.txt", "created 2020-01-02T00:00:00Z before 2020-01-05T00:00:00Z"},
+		{"disk.vhdx", "file type vhdx excluded"},
+		{"large.txt", "size 100 above 50"},
+		{"old.txt", "modified 2020-01-01T00:00:00Z before 2020-01-05T00:00:00Z"},
+	}
+	if !reflect.DeepEqual(sources[0].Filtered, wantFiltered) {
+		t.Errorf("Run() filtered = %#v, want %#v", sources[0].Filtered, wantFiltered)
+	}
+	if sources[1].Status != StatusSkipped || sources[1].Error == "" || sources[2].Status != StatusCollected {
+		t.Errorf("Run() sources = %#v", sources)
+	}
+	var collected []string
+	for _, source := range collector.Collected["Files"] {
+		collected = append(collected, source.Attributes.Paths...)
+	}
+	if want := []string{"app.exe", "new.txt", "old.txt"}; !reflect.DeepEqual(collected, want) {
+		t.Errorf("Run() collected = %v, want %v", collected, want)
+	}
+
+	onlyPE := &FileFilter{Types: []string{"pe"}}
+	if reason, err := onlyPE.Check(fsys, "new.txt"); err != nil || reason != "file type unknown not selected" {
+		t.Errorf("Check() = %q, %v", reason, err)
+	}
+	if reason, err := onlyPE.Check(fsys, "app.exe"); err != nil || reason != "" {
+		t.Errorf("Check() = %q, %v", reason, err)
+	}
+
+	collector = &TestCollector{fs: fsys}
+	report = Run(definitions, []string{"Files"}, collector, RunOptions{OS: "Linux", FileFilter: &FileFilter{Types: []string{"exe"}}})
+	if report.Error != "unknown file type exe" || len(report.Artifacts) != 0 || len(collector.Collected) != 0 {
+		t.Errorf("Run() = %#v", report)
+	}
+}
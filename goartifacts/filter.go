@@ -22,6 +22,7 @@
 package goartifacts
 
 import (
+	"log"
 	"runtime"
 	"strings"
 )
@@ -46,15 +47,25 @@ func FilterOS(artifactDefinitions []ArtifactDefinition) []ArtifactDefinition {
 }
 
 // FilterName return a list of ArtifactDefinitions which match the provided
-// names.
+// names. Artifact groups are resolved and artifacts and sources that do not
+// support the current operating system are removed. The definitions are
+// returned in selection order, see SelectArtifacts for error handling.
 func FilterName(names []string, artifactDefinitions []ArtifactDefinition) []ArtifactDefinition {
-	artifactDefinitionMap := map[string]ArtifactDefinition{}
-	for _, artifactDefinition := range artifactDefinitions {
-		artifactDefinitionMap[artifactDefinition.Name] = artifactDefinition
+	selected, err := SelectArtifacts(names, artifactDefinitions, SelectOptions{})
+	if err != nil {
+		log.Println(err)
 	}
 	var artifactList []ArtifactDefinition
-	for _, artifact := range expandArtifactGroup(names, artifactDefinitionMap) {
-		artifactList = append(artifactList, artifact)
+	for _, artifact := range selected {
+		artifactDefinition := artifact.Definition
+		var sources []Source
+		for _, source := range artifactDefinition.Sources {
+			if IsOSArtifactDefinition(runtime.GOOS, source.SupportedOs) {
+				sources = append(sources, source)
+			}
+		}
+		artifactDefinition.Sources = sources
+		artifactList = append(artifactList, artifactDefinition)
 	}
 	return artifactList
 }
@@ -0,0 +1,252 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"sort"
+	"strings"
+)
+
+// GroupGraph is the directed graph of artifact definitions formed by
+// ARTIFACT_GROUP sources, with edges from groups to their members. Node
+// lists are ordered like the artifact definitions.
+type GroupGraph struct {
+	nodes    []string
+	index    map[string]int
+	children map[string][]string
+	parents  map[string][]string
+}
+
+// CycleError is returned by TopologicalOrder for graphs with cycles.
+type CycleError struct {
+	// Nodes lists all nodes that are part of or depend on a cycle.
+	Nodes []string
+}
+
+func (e *CycleError) Error() string {
+	return "artifact groups contain a cycle: " + strings.Join(e.Nodes, ", ")
+}
+
+// NewGroupGraph creates the group graph for an operating system. Artifacts
+// and group sources that do not support the operating system are not part
+// of the graph, unless the operating system is empty. Members that are not
+// defined are ignored.
+func NewGroupGraph(artifactDefinitions []ArtifactDefinition, operatingSystem string) *GroupGraph {
+	supported := func(supportedOs []string) bool {
+		return operatingSystem == "" || IsOSArtifactDefinition(operatingSystem, supportedOs)
+	}
+
+	g := &GroupGraph{index: map[string]int{}, children: map[string][]string{}, parents: map[string][]string{}}
+	for _, artifactDefinition := range artifactDefinitions {
+		if _, ok := g.index[artifactDefinition.Name]; !ok && supported(artifactDefinition.SupportedOs) {
+			g.index[artifactDefinition.Name] = len(g.nodes)
+			g.nodes = append(g.nodes, artifactDefinition.Name)
+		}
+	}
+	for _, artifactDefinition := range artifactDefinitions {
+		if _, ok := g.index[artifactDefinition.Name]; !ok {
+			continue
+		}
+		for _, source := range artifactDefinition.Sources {
+			if source.Type != SourceType.ArtifactGroup || !supported(source.SupportedOs) {
+				continue
+			}
+			for _, member := range source.Attributes.Names {
+				if _, ok := g.index[member]; !ok || containsString(g.children[artifactDefinition.Name], member) {
+					continue
+				}
+				g.children[artifactDefinition.Name] = append(g.children[artifactDefinition.Name], member)
+				g.parents[member] = append(g.parents[member], artifactDefinition.Name)
+			}
+		}
+	}
+	for _, parents := range g.parents {
+		g.sort(parents)
+	}
+	return g
+}
+
+// Nodes returns all artifacts in the graph.
+func (g *GroupGraph) Nodes() []string {
+	return appendString(nil, g.nodes...)
+}
+
+// Children returns the direct members of a group.
+func (g *GroupGraph) Children(name string) []string {
+	return appendString(nil, g.children[name]...)
+}
+
+// Parents returns the groups that directly contain an artifact.
+func (g *GroupGraph) Parents(name string) []string {
+	return appendString(nil, g.parents[name]...)
+}
+
+// Descendants returns all artifacts reachable from a group, in breadth first
+// order.
+func (g *GroupGraph) Descendants(name string) []string {
+	return g.reachable(name, g.children)
+}
+
+// Ancestors returns all groups that contain an artifact directly or
+// indirectly, in breadth first order.
+func (g *GroupGraph) Ancestors(name string) []string {
+	return g.reachable(name, g.parents)
+}
+
+// Closure returns the transitive closure of the graph, the descendants of
+// every group.
+func (g *GroupGraph) Closure() map[string][]string {
+	closure := map[string][]string{}
+	for _, name := range g.nodes {
+		if len(g.children[name]) > 0 {
+			closure[name] = g.Descendants(name)
+		}
+	}
+	return closure
+}
+
+// Roots returns all groups that are not contained in another group.
+func (g *GroupGraph) Roots() []string {
+	var roots []string
+	for _, name := range g.nodes {
+		if len(g.parents[name]) == 0 && len(g.children[name]) > 0 {
+			roots = append(roots, name)
+		}
+	}
+	return roots
+}
+
+// Orphans returns all artifacts that are neither groups nor contained in a
+// group.
+func (g *GroupGraph) Orphans() []string {
+	var orphans []string
+	for _, name := range g.nodes {
+		if len(g.parents[name]) == 0 && len(g.children[name]) == 0 {
+			orphans = append(orphans, name)
+		}
+	}
+	return orphans
+}
+
+// TopologicalOrder returns all artifacts so that every group precedes its
+// members. Otherwise the order of the definitions is kept. A *CycleError is
+// returned if the graph contains cycles.
+func (g *GroupGraph) TopologicalOrder() ([]string, error) {
+	indegree := map[string]int{}
+	for _, name := range g.nodes {
+		indegree[name] = len(g.parents[name])
+	}
+
+	var ready, sorted []string
+	for _, name := range g.nodes {
+		if indegree[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+	for len(ready) > 0 {
+		g.sort(ready)
+		name := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, name)
+		for _, member := range g.children[name] {
+			indegree[member]--
+			if indegree[member] == 0 {
+				ready = append(ready, member)
+			}
+		}
+	}
+
+	if len(sorted) < len(g.nodes) {
+		var cyclic []string
+		for _, name := range g.nodes {
+			if indegree[name] > 0 {
+				cyclic = append(cyclic, name)
+			}
+		}
+		return sorted, &CycleError{Nodes: cyclic}
+	}
+	return sorted, nil
+}
+
+// ShortestPath returns the shortest path of group memberships from one
+// artifact to another, including both. It returns nil if there is no path.
+func (g *GroupGraph) ShortestPath(from, to string) []string {
+	if _, ok := g.index[from]; !ok {
+		return nil
+	}
+	if _, ok := g.index[to]; !ok {
+		return nil
+	}
+
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if name == to {
+			var path []string
+			for ; name != from; name = previous[name] {
+				path = append([]string{name}, path...)
+			}
+			return append([]string{from}, path...)
+		}
+		for _, member := range g.children[name] {
+			if _, ok := previous[member]; !ok {
+				previous[member] = name
+				queue = append(queue, member)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *GroupGraph) reachable(name string, edges map[string][]string) []string {
+	var reached []string
+	visited := map[string]bool{name: true}
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range edges[current] {
+			if !visited[next] {
+				visited[next] = true
+				reached = append(reached, next)
+				queue = append(queue, next)
+			}
+		}
+	}
+	return reached
+}
+
+// sort sorts names by the order of their definitions.
+func (g *GroupGraph) sort(names []string) {
+	sort.Sort(byIndex{names, g.index})
+}
+
+type byIndex struct {
+	names []string
+	index map[string]int
+}
+
+func (a byIndex) Len() int           { return len(a.names) }
+func (a byIndex) Swap(i, j int)      { a.names[i], a.names[j] = a.names[j], a.names[i] }
+func (a byIndex) Less(i, j int) bool { return a.index[a.names[i]] < a.index[a.names[j]] }
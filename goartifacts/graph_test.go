@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupGraph(t *testing.T) {
+	definitions := append(getRunDefinitions(), ArtifactDefinition{Name: "Top", Sources: []Source{
+		{Type: SourceType.ArtifactGroup, Attributes: Attributes{Names: []string{"Nested", "Missing"}}},
+		{Type: SourceType.ArtifactGroup, Attributes: Attributes{Names: []string{"Panic"}}, SupportedOs: []string{"Windows"}},
+	}})
+
+	g := NewGroupGraph(definitions, "Linux")
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"nodes", g.Nodes(), []string{"Group", "Nested", "A", "B", "C", "Panic", "Top"}},
+		{"children", g.Children("Nested"), []string{"B", "A"}},
+		{"parents", g.Parents("A"), []string{"Group", "Nested"}},
+		{"descendants", g.Descendants("Group"), []string{"C", "Nested", "A", "B"}},
+		{"ancestors", g.Ancestors("B"), []string{"Nested", "Group", "Top"}},
+		{"roots", g.Roots(), []string{"Group", "Top"}},
+		{"orphans", g.Orphans(), []string{"Panic"}},
+		{"path", g.ShortestPath("Group", "B"), []string{"Group", "Nested", "B"}},
+		{"direct path", g.ShortestPath("Group", "A"), []string{"Group", "A"}},
+		{"no path", g.ShortestPath("Nested", "C"), []string(nil)},
+		{"closure", g.Closure(), map[string][]string{
+			"Group":  {"C", "Nested", "A", "B"},
+			"Nested": {"B", "A"},
+			"Top":    {"Nested", "B", "A"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Group", "C", "Panic", "Top", "Nested", "A", "B"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("TopologicalOrder() = %v, want %v", order, want)
+	}
+
+	windows := NewGroupGraph(definitions, "Windows")
+	if got := windows.Children("Top"); !reflect.DeepEqual(got, []string{"Nested", "Panic"}) {
+		t.Errorf("Children() on Windows = %v", got)
+	}
+	if got := windows.Children("Nested"); !reflect.DeepEqual(got, []string{"B", "A"}) {
+		t.Errorf("Children() on Windows = %v", got)
+	}
+	if got := NewGroupGraph(definitions, "").Children("Nested"); !reflect.DeepEqual(got, []string{"B", "A", "Other"}) {
+		t.Errorf("Children() on all OS = %v", got)
+	}
+}
+
+func TestGroupGraphCycle(t *testing.T) {
+	group := func(names ...string) Source {
+		return Source{Type: SourceType.ArtifactGroup, Attributes: Attributes{Names: names}}
+	}
+	g := NewGroupGraph([]ArtifactDefinition{
+		{Name: "X", Sources: []Source{group("Y")}},
+		{Name: "Y", Sources: []Source{group("X", "Z")}},
+		{Name: "Z"},
+		{Name: "W", Sources: []Source{group("Z")}},
+	}, "")
+	order, err := g.TopologicalOrder()
+	cycleErr, ok := err.(*CycleError)
+	if !ok || !reflect.DeepEqual(cycleErr.Nodes, []string{"X", "Y", "Z"}) {
+		t.Errorf("TopologicalOrder() error = %v", err)
+	}
+	if !reflect.DeepEqual(order, []string{"W"}) {
+		t.Errorf("TopologicalOrder() = %v", order)
+	}
+	if got := g.Descendants("X"); !reflect.DeepEqual(got, []string{"Y", "Z"}) {
+		t.Errorf("Descendants() = %v", got)
+	}
+}
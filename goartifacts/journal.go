@@ -0,0 +1,200 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// JournalMismatchError is returned if a journal was written for other
+// artifact definitions.
+type JournalMismatchError struct {
+	Journal     string
+	Definitions string
+}
+
+func (e *JournalMismatchError) Error() string {
+	return fmt.Sprintf("journal was written for definitions %s, not %s", e.Journal, e.Definitions)
+}
+
+// Journal is an append-only record of finished work in a run. Every line of
+// the journal file is a JSON object. The first line contains the hash of
+// the artifact definitions, all others a finished (artifact, source, item)
+// tuple. Sources that are not expanded into items are recorded with an
+// empty item.
+type Journal struct {
+	file        *os.File
+	definitions string
+	done        map[string]bool
+}
+
+type journalEntry struct {
+	Definitions string     `json:"definitions,omitempty"`
+	Created     *time.Time `json:"created,omitempty"`
+	Artifact    string     `json:"artifact,omitempty"`
+	Source      int        `json:"source"`
+	Item        string     `json:"item,omitempty"`
+}
+
+// OpenJournal opens the journal file or creates it if it does not exist. A
+// *JournalMismatchError is returned if the journal was written for other
+// artifact definitions.
+func OpenJournal(name string, artifactDefinitions []ArtifactDefinition) (*Journal, error) {
+	definitions, err := HashDefinitions(artifactDefinitions)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600) // #nosec
+	if err != nil {
+		return nil, err
+	}
+	journal := &Journal{file: file, definitions: definitions, done: map[string]bool{}}
+	if err := journal.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return journal, nil
+}
+
+// load reads the journal. An incomplete last line, e.g. from a crash, is
+// removed.
+func (j *Journal) load() error {
+	reader := bufio.NewReader(j.file)
+	var offset int64
+	header := true
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("invalid journal entry at offset %d: %s", offset, err)
+		}
+		offset += int64(len(line))
+
+		if header {
+			if entry.Definitions != j.definitions {
+				return &JournalMismatchError{Journal: entry.Definitions, Definitions: j.definitions}
+			}
+			header = false
+			continue
+		}
+		j.done[journalKey(entry.Artifact, entry.Source, entry.Item)] = true
+	}
+
+	if err := j.file.Truncate(offset); err != nil {
+		return err
+	}
+	if header {
+		now := time.Now().UTC()
+		return j.write([]journalEntry{{Definitions: j.definitions, Created: &now}})
+	}
+	return nil
+}
+
+// Definitions returns the hash of the artifact definitions of the journal.
+func (j *Journal) Definitions() string {
+	return j.definitions
+}
+
+// Done returns if an item of a source was recorded as finished.
+func (j *Journal) Done(artifact string, source int, item string) bool {
+	return j.done[journalKey(artifact, source, item)]
+}
+
+// Record appends finished items of a source to the journal and syncs it to
+// the storage.
+func (j *Journal) Record(artifact string, source int, items []string) error {
+	entries := make([]journalEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, journalEntry{Artifact: artifact, Source: source, Item: item})
+	}
+	if err := j.write(entries); err != nil {
+		return err
+	}
+	for _, item := range items {
+		j.done[journalKey(artifact, source, item)] = true
+	}
+	return nil
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+func (j *Journal) write(entries []journalEntry) error {
+	buf := &bytes.Buffer{}
+	for _, entry := range entries {
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	if _, err := j.file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// filter returns the items that are not finished yet and those that are.
+func (j *Journal) filter(artifact string, source int, items []string) (remaining, done []string) {
+	for _, item := range items {
+		if j.Done(artifact, source, item) {
+			done = append(done, item)
+		} else {
+			remaining = append(remaining, item)
+		}
+	}
+	return remaining, done
+}
+
+// check verifies that the journal was opened for the artifact definitions.
+func (j *Journal) check(artifactDefinitions []ArtifactDefinition) error {
+	definitions, err := HashDefinitions(artifactDefinitions)
+	if err != nil {
+		return err
+	}
+	if definitions != j.definitions {
+		return &JournalMismatchError{Journal: j.definitions, Definitions: definitions}
+	}
+	return nil
+}
+
+func journalKey(artifact string, source int, item string) string {
+	return artifact + "\x00" + strconv.Itoa(source) + "\x00" + item
+}
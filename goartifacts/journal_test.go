@@ -0,0 +1,114 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func getJournalDefinitions() []ArtifactDefinition {
+	return []ArtifactDefinition{
+		{Name: "A", Sources: []Source{
+			{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/dir/*.bin"}}},
+			{Type: SourceType.Command, Attributes: Attributes{Cmd: "id"}},
+		}},
+		{Name: "Panic", Sources: []Source{{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/foo.bin"}}}}},
+	}
+}
+
+func TestJournalResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "journal.jsonl")
+	definitions := getJournalDefinitions()
+
+	journal, err := OpenJournal(name, definitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := &panicCollector{TestCollector{fs: getInFS()}}
+	report := Run(definitions, []string{"A", "Panic"}, collector, RunOptions{OS: "Linux", Journal: journal})
+	if len(report.Failed()) != 1 {
+		t.Fatalf("Run() failed = %#v", report.Failed())
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate an interrupted write
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"artifact":"Pan`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	journal, err = OpenJournal(name, definitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	if !journal.Done("A", 0, "dir/bar.bin") || !journal.Done("A", 1, "") || journal.Done("Panic", 0, "foo.bin") {
+		t.Errorf("journal = %v", journal.done)
+	}
+
+	other := append(getJournalDefinitions(), ArtifactDefinition{Name: "B"})
+	if report := Run(other, []string{"A"}, collector, RunOptions{OS: "Linux", Journal: journal}); report.Error == "" || len(report.Artifacts) != 0 {
+		t.Errorf("Run() with other definitions = %#v", report)
+	}
+	if _, err := OpenJournal(name, other); err == nil {
+		t.Error("OpenJournal() with other definitions succeeded")
+	} else if _, ok := err.(*JournalMismatchError); !ok {
+		t.Errorf("OpenJournal() error = %v, want JournalMismatchError", err)
+	}
+
+	resumed := &TestCollector{fs: getInFS()}
+	report = Run(definitions, []string{"A", "Panic"}, resumed, RunOptions{OS: "Linux", Journal: journal})
+	a := report.Artifacts[0]
+	if a.Status != StatusCollected || a.Sources[0].Status != StatusResumed || a.Sources[1].Status != StatusResumed {
+		t.Errorf("Run() sources = %#v", a.Sources)
+	}
+	if !reflect.DeepEqual(a.Sources[0].Resumed, []string{"dir/bar.bin", "dir/baz.bin"}) {
+		t.Errorf("Run() resumed = %v", a.Sources[0].Resumed)
+	}
+	if len(resumed.Collected) != 1 || len(resumed.Collected["Panic"]) != 1 || !journal.Done("Panic", 0, "foo.bin") {
+		t.Errorf("Collect() calls = %#v", resumed.Collected)
+	}
+
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n"); len(lines) != 5 {
+		t.Errorf("journal = %q, want header and 4 entries", b)
+	}
+}
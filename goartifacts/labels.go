@@ -0,0 +1,224 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LabelFilter selects artifact definitions by their labels. Labels are
+// compared case-insensitive. An empty filter matches all definitions.
+type LabelFilter struct {
+	// Any requires at least one of the labels.
+	Any []string `yaml:"any,omitempty"`
+	// All requires all of the labels.
+	All []string `yaml:"all,omitempty"`
+	// None excludes definitions with any of the labels.
+	None []string `yaml:"none,omitempty"`
+}
+
+// Match returns whether the labels satisfy the filter.
+func (f LabelFilter) Match(labels []string) bool {
+	if len(f.Any) > 0 && !hasAnyLabel(labels, f.Any) {
+		return false
+	}
+	for _, label := range f.All {
+		if !hasAnyLabel(labels, []string{label}) {
+			return false
+		}
+	}
+	return !hasAnyLabel(labels, f.None)
+}
+
+func hasAnyLabel(labels, wanted []string) bool {
+	for _, label := range labels {
+		for _, w := range wanted {
+			if strings.EqualFold(label, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FilterLabels returns the artifact definitions whose labels match the
+// filter. Artifact groups are not resolved, so the filter should be applied
+// to selected definitions, e.g. the result of FilterName.
+func FilterLabels(artifactDefinitions []ArtifactDefinition, filter LabelFilter) []ArtifactDefinition {
+	var selected []ArtifactDefinition
+	for _, artifactDefinition := range artifactDefinitions {
+		if filter.Match(artifactDefinition.Labels) {
+			selected = append(selected, artifactDefinition)
+		}
+	}
+	return selected
+}
+
+// FilterURLHosts returns the artifact definitions that reference a URL on one
+// of the hosts. Subdomains of a host match as well, so github.com matches
+// raw.github.com.
+func FilterURLHosts(artifactDefinitions []ArtifactDefinition, hosts ...string) []ArtifactDefinition {
+	var selected []ArtifactDefinition
+	for _, artifactDefinition := range artifactDefinitions {
+		if hasURLHost(artifactDefinition.Urls, hosts) {
+			selected = append(selected, artifactDefinition)
+		}
+	}
+	return selected
+}
+
+func hasURLHost(urls, hosts []string) bool {
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			continue
+		}
+		host := strings.ToLower(parsed.Host)
+		if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "]") {
+			host = host[:i]
+		}
+		for _, h := range hosts {
+			h = strings.ToLower(h)
+			if host == h || strings.HasSuffix(host, "."+h) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Label is a label of the taxonomy.
+type Label struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+}
+
+// LabelTaxonomy maps known labels to their description.
+type LabelTaxonomy map[string]string
+
+// DefaultLabels returns the labels used by the forensic artifacts
+// repository. The returned taxonomy can be extended with custom labels.
+func DefaultLabels() LabelTaxonomy {
+	return LabelTaxonomy{
+		"Antivirus":           "Antivirus related artifacts, e.g. quarantine files.",
+		"Authentication":      "Authentication artifacts.",
+		"Browser":             "Web browser artifacts.",
+		"Cloud":               "Cloud application artifacts.",
+		"Cloud Storage":       "Cloud storage artifacts.",
+		"Configuration Files": "Configuration file artifacts.",
+		"Docker":              "Docker artifacts.",
+		"ExternalAccount":     "Information about user accounts, e.g. user name or account ID.",
+		"ExternalMedia":       "Information about media external to the local computer.",
+		"Hadoop":              "Hadoop artifacts.",
+		"History Files":       "History file artifacts, e.g. .bash_history.",
+		"IM":                  "Instant messaging and chat application artifacts.",
+		"iOS":                 "Artifacts of iOS devices connected to the system.",
+		"KnowledgeBase":       "Artifacts used to build the knowledge base.",
+		"Logs":                "Log files.",
+		"Mail":                "Mail client artifacts.",
+		"Memory":              "Artifacts retrieved from memory.",
+		"Network":             "Networking state.",
+		"Processes":           "Running processes.",
+		"Software":            "Installed software.",
+		"System":              "Core system artifacts.",
+		"Users":               "Information about users.",
+	}
+}
+
+// Add adds or replaces labels.
+func (t LabelTaxonomy) Add(labels ...Label) {
+	for _, label := range labels {
+		t[label.Name] = label.Description
+	}
+}
+
+// DecodeLabels decodes a YAML list of labels with name and description,
+// e.g. to extend a taxonomy.
+func DecodeLabels(r io.Reader) ([]Label, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.SetStrict(true)
+
+	var labels []Label
+	if err := decoder.Decode(&labels); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// LoadLabels decodes the labels of a file.
+func LoadLabels(filename string) ([]Label, error) {
+	f, err := os.Open(filename) // #nosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeLabels(f)
+}
+
+// Labels returns the labels sorted by name.
+func (t LabelTaxonomy) Labels() []Label {
+	var labels []Label
+	for name, description := range t {
+		labels = append(labels, Label{Name: name, Description: description})
+	}
+	sort.Sort(byLabelName(labels))
+	return labels
+}
+
+// Validate returns the labels of the artifact definition that are not part
+// of the taxonomy. Labels are compared case-sensitive.
+func (t LabelTaxonomy) Validate(artifactDefinition ArtifactDefinition) []string {
+	var unknown []string
+	for _, label := range artifactDefinition.Labels {
+		if _, ok := t[label]; !ok {
+			unknown = append(unknown, label)
+		}
+	}
+	return unknown
+}
+
+// CountLabels returns the number of artifact definitions per label.
+func CountLabels(artifactDefinitions []ArtifactDefinition) map[string]int {
+	counts := map[string]int{}
+	for _, artifactDefinition := range artifactDefinitions {
+		seen := map[string]bool{}
+		for _, label := range artifactDefinition.Labels {
+			if !seen[label] {
+				seen[label] = true
+				counts[label]++
+			}
+		}
+	}
+	return counts
+}
+
+type byLabelName []Label
+
+func (s byLabelName) Len() int           { return len(s) }
+func (s byLabelName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byLabelName) Less(i, j int) bool { return s[i].Name < s[j].Name }
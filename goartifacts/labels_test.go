@@ -0,0 +1,125 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func labelTestDefinitions() []ArtifactDefinition {
+	return []ArtifactDefinition{
+		{Name: "ChromeHistory", Labels: []string{"Browser"}, Urls: []string{"https://forensicswiki.xyz/wiki/index.php?title=Google_Chrome"}},
+		{Name: "FirefoxLogs", Labels: []string{"Browser", "Logs"}, Urls: []string{"https://developer.mozilla.org:443/en-US/docs"}},
+		{Name: "SyslogFiles", Labels: []string{"Logs", "logs"}, Urls: []string{"https://github.com/ForensicArtifacts/artifacts"}},
+		{Name: "Hostname", Urls: []string{"http://[::1]:8080/hostname"}},
+	}
+}
+
+func definitionNames(artifactDefinitions []ArtifactDefinition) []string {
+	var n []string
+	for _, artifactDefinition := range artifactDefinitions {
+		n = append(n, artifactDefinition.Name)
+	}
+	return n
+}
+
+func TestFilterLabels(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter LabelFilter
+		want   []string
+	}{
+		{"empty", LabelFilter{}, []string{"ChromeHistory", "FirefoxLogs", "SyslogFiles", "Hostname"}},
+		{"any", LabelFilter{Any: []string{"browser", "Mail"}}, []string{"ChromeHistory", "FirefoxLogs"}},
+		{"all", LabelFilter{All: []string{"Browser", "Logs"}}, []string{"FirefoxLogs"}},
+		{"none", LabelFilter{None: []string{"Logs"}}, []string{"ChromeHistory", "Hostname"}},
+		{"combined", LabelFilter{Any: []string{"Logs"}, None: []string{"Browser"}}, []string{"SyslogFiles"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := definitionNames(FilterLabels(labelTestDefinitions(), tt.filter)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterURLHosts(t *testing.T) {
+	tests := []struct {
+		name  string
+		hosts []string
+		want  []string
+	}{
+		{"host", []string{"GitHub.com"}, []string{"SyslogFiles"}},
+		{"subdomain", []string{"mozilla.org"}, []string{"FirefoxLogs"}},
+		{"ipv6", []string{"[::1]"}, []string{"Hostname"}},
+		{"multiple", []string{"forensicswiki.xyz", "github.com"}, []string{"ChromeHistory", "SyslogFiles"}},
+		{"suffix only", []string{"hub.com"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := definitionNames(FilterURLHosts(labelTestDefinitions(), tt.hosts...)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterURLHosts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelTaxonomy(t *testing.T) {
+	taxonomy := DefaultLabels()
+	taxonomy.Add(Label{Name: "Triage", Description: "Artifacts for fast triage."})
+
+	labels := taxonomy.Labels()
+	if len(labels) != len(DefaultLabels())+1 || labels[0].Name != "Antivirus" {
+		t.Errorf("Labels() = %v", labels)
+	}
+
+	definition := ArtifactDefinition{Labels: []string{"Browser", "Triage", "Unknown", "logs"}}
+	if got := taxonomy.Validate(definition); !reflect.DeepEqual(got, []string{"Unknown", "logs"}) {
+		t.Errorf("Validate() = %v", got)
+	}
+	if got := DefaultLabels().Validate(definition); !reflect.DeepEqual(got, []string{"Triage", "Unknown", "logs"}) {
+		t.Errorf("Validate() = %v", got)
+	}
+}
+
+func TestDecodeLabels(t *testing.T) {
+	labels, err := DecodeLabels(strings.NewReader("- name: Triage\n  description: Artifacts for fast triage.\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Label{{Name: "Triage", Description: "Artifacts for fast triage."}}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("DecodeLabels() = %v, want %v", labels, want)
+	}
+	if _, err := DecodeLabels(strings.NewReader("- name: Triage\n  desc: typo\n")); err == nil {
+		t.Error("DecodeLabels() accepted unknown field")
+	}
+}
+
+func TestCountLabels(t *testing.T) {
+	want := map[string]int{"Browser": 2, "Logs": 2, "logs": 1}
+	if got := CountLabels(labelTestDefinitions()); !reflect.DeepEqual(got, want) {
+		t.Errorf("CountLabels() = %v, want %v", got, want)
+	}
+}
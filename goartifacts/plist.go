@@ -0,0 +1,345 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// decodePlist decodes XML and binary property lists as used on macOS.
+// Dictionaries are decoded into map[string]interface{} and arrays into
+// []interface{}. Only the types needed to read account information are
+// supported.
+func decodePlist(data []byte) (interface{}, error) {
+	if bytes.HasPrefix(data, []byte("bplist00")) {
+		return decodeBinaryPlist(data)
+	}
+	return decodeXMLPlist(data)
+}
+
+func decodeXMLPlist(data []byte) (interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid plist: %s", err)
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local != "plist" {
+			return decodeXMLPlistValue(decoder, start)
+		}
+	}
+}
+
+func decodeXMLPlistValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) { // nolint:gocyclo
+	switch start.Name.Local {
+	case "dict":
+		dict := map[string]interface{}{}
+		key := ""
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch token := token.(type) {
+			case xml.EndElement:
+				return dict, nil
+			case xml.StartElement:
+				if token.Name.Local == "key" {
+					if err := decoder.DecodeElement(&key, &token); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value, err := decodeXMLPlistValue(decoder, token)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+			}
+		}
+	case "array":
+		var array []interface{}
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch token := token.(type) {
+			case xml.EndElement:
+				return array, nil
+			case xml.StartElement:
+				value, err := decodeXMLPlistValue(decoder, token)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			}
+		}
+	case "true", "false":
+		if err := decoder.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	var text string
+	if err := decoder.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "integer":
+		return strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	case "real":
+		return strconv.ParseFloat(strings.TrimSpace(text), 64)
+	}
+	return text, nil
+}
+
+// binaryPlist holds the state to decode a binary property list.
+type binaryPlist struct {
+	data          []byte
+	offsets       []uint64
+	objectRefSize int
+	// decoded caches all decoded objects, so objects referenced multiple
+	// times are decoded once. decoding contains the objects that are
+	// currently decoded to detect reference cycles.
+	decoded  map[uint64]interface{}
+	decoding map[uint64]bool
+}
+
+func decodeBinaryPlist(data []byte) (interface{}, error) {
+	const trailerSize = 32
+	if len(data) < len("bplist00")+trailerSize {
+		return nil, errors.New("invalid binary plist: too short")
+	}
+	trailer := data[len(data)-trailerSize:]
+	offsetIntSize := int(trailer[6])
+	objectRefSize := int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:16])
+	topObject := binary.BigEndian.Uint64(trailer[16:24])
+	offsetTableOffset := binary.BigEndian.Uint64(trailer[24:32])
+
+	p := &binaryPlist{data: data, objectRefSize: objectRefSize, decoded: map[uint64]interface{}{}, decoding: map[uint64]bool{}}
+	if offsetIntSize == 0 || objectRefSize == 0 {
+		return nil, errors.New("invalid binary plist: broken trailer")
+	}
+	table, err := p.array(offsetTableOffset, numObjects, uint64(offsetIntSize))
+	if err != nil {
+		return nil, errors.New("invalid binary plist: broken trailer")
+	}
+	for i := uint64(0); i < numObjects; i++ {
+		start := i * uint64(offsetIntSize)
+		p.offsets = append(p.offsets, readUint(table[start:start+uint64(offsetIntSize)]))
+	}
+	return p.object(topObject)
+}
+
+func readUint(b []byte) uint64 {
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u
+}
+
+// bytes returns length bytes at offset. The bounds are checked without
+// additions, so offsets and lengths read from the file cannot overflow.
+func (p *binaryPlist) bytes(offset, length uint64) ([]byte, error) {
+	size := uint64(len(p.data))
+	if offset > size || length > size-offset {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return p.data[offset : offset+length], nil
+}
+
+// array returns count elements of size bytes at offset.
+func (p *binaryPlist) array(offset, count, size uint64) ([]byte, error) {
+	if size != 0 && count > uint64(len(p.data))/size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return p.bytes(offset, count*size)
+}
+
+// length returns the length of an object and the offset of its content.
+func (p *binaryPlist) length(offset uint64, marker byte) (uint64, uint64, error) {
+	length := uint64(marker & 0x0f)
+	if length != 0x0f {
+		return length, offset + 1, nil
+	}
+	b, err := p.bytes(offset+1, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	size := uint64(1) << (b[0] & 0x0f)
+	b, err = p.bytes(offset+2, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	return readUint(b), offset + 2 + size, nil
+}
+
+func (p *binaryPlist) refs(offset, count uint64) ([]uint64, error) {
+	b, err := p.array(offset, count, uint64(p.objectRefSize))
+	if err != nil {
+		return nil, err
+	}
+	var refs []uint64
+	for i := uint64(0); i < count; i++ {
+		refs = append(refs, readUint(b[i*uint64(p.objectRefSize):(i+1)*uint64(p.objectRefSize)]))
+	}
+	return refs, nil
+}
+
+// object returns the decoded object. Objects are decoded only once and
+// references to an object that is currently decoded are rejected.
+func (p *binaryPlist) object(ref uint64) (interface{}, error) {
+	if value, ok := p.decoded[ref]; ok {
+		return value, nil
+	}
+	if p.decoding[ref] {
+		return nil, errors.New("invalid binary plist: reference cycle")
+	}
+	if len(p.decoding) >= 512 {
+		return nil, errors.New("invalid binary plist: nested too deep")
+	}
+	p.decoding[ref] = true
+	value, err := p.decodeObject(ref)
+	delete(p.decoding, ref)
+	if err != nil {
+		return nil, err
+	}
+	p.decoded[ref] = value
+	return value, nil
+}
+
+func (p *binaryPlist) decodeObject(ref uint64) (interface{}, error) { // nolint:gocyclo
+	if ref >= uint64(len(p.offsets)) {
+		return nil, errors.New("invalid binary plist: unknown object")
+	}
+
+	offset := p.offsets[ref]
+	b, err := p.bytes(offset, 1)
+	if err != nil {
+		return nil, err
+	}
+	marker := b[0]
+
+	switch marker >> 4 {
+	case 0x0:
+		return marker == 0x09, nil
+	case 0x1:
+		b, err := p.bytes(offset+1, 1<<(marker&0x0f))
+		if err != nil {
+			return nil, err
+		}
+		return int64(readUint(b)), nil
+	case 0x2:
+		b, err := p.bytes(offset+1, 1<<(marker&0x0f))
+		if err != nil {
+			return nil, err
+		}
+		if len(b) == 4 {
+			return float64(math.Float32frombits(uint32(readUint(b)))), nil
+		}
+		return math.Float64frombits(readUint(b)), nil
+	case 0x3:
+		// dates are seconds since 2001-01-01
+		b, err := p.bytes(offset+1, 8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(readUint(b)), nil
+	case 0x8:
+		b, err := p.bytes(offset+1, uint64(marker&0x0f)+1)
+		if err != nil {
+			return nil, err
+		}
+		return int64(readUint(b)), nil
+	}
+
+	length, start, err := p.length(offset, marker)
+	if err != nil {
+		return nil, err
+	}
+	switch marker >> 4 {
+	case 0x4:
+		return p.bytes(start, length)
+	case 0x5:
+		b, err := p.bytes(start, length)
+		return string(b), err
+	case 0x6:
+		b, err := p.array(start, length, 2)
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]uint16, length)
+		for i := range runes {
+			runes[i] = binary.BigEndian.Uint16(b[2*i:])
+		}
+		return string(utf16.Decode(runes)), nil
+	case 0xa:
+		refs, err := p.refs(start, length)
+		if err != nil {
+			return nil, err
+		}
+		var array []interface{}
+		for _, ref := range refs {
+			value, err := p.object(ref)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		return array, nil
+	case 0xd:
+		if length > uint64(len(p.data)) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		refs, err := p.refs(start, 2*length)
+		if err != nil {
+			return nil, err
+		}
+		dict := map[string]interface{}{}
+		for i := uint64(0); i < length; i++ {
+			key, err := p.object(refs[i])
+			if err != nil {
+				return nil, err
+			}
+			value, err := p.object(refs[length+i])
+			if err != nil {
+				return nil, err
+			}
+			dict[fmt.Sprint(key)] = value
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("invalid binary plist: unsupported marker %x", marker)
+}
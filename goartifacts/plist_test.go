@@ -0,0 +1,140 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// binaryPlistData returns a binary plist with the objects and the offset
+// table followed by a trailer with the given values.
+func binaryPlistData(body []byte, offsetIntSize, objectRefSize byte, numObjects, topObject, offsetTableOffset uint64) []byte {
+	data := append([]byte("bplist00"), body...)
+	trailer := make([]byte, 32)
+	trailer[6] = offsetIntSize
+	trailer[7] = objectRefSize
+	binary.BigEndian.PutUint64(trailer[8:16], numObjects)
+	binary.BigEndian.PutUint64(trailer[16:24], topObject)
+	binary.BigEndian.PutUint64(trailer[24:32], offsetTableOffset)
+	return append(data, trailer...)
+}
+
+func TestDecodeBinaryPlistInvalid(t *testing.T) {
+	const maxUint64 = ^uint64(0)
+	// objects followed by an offset table with one entry pointing to 8
+	object := func(object ...byte) []byte { return append(object, 8) }
+	tableOffset := func(object []byte) uint64 { return uint64(8 + len(object) - 1) }
+	huge := []byte{0x13, 0x80, 0, 0, 0, 0, 0, 0, 1}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too short", []byte("bplist00")},
+		{"no offset size", binaryPlistData(nil, 0, 1, 0, 0, 8)},
+		{"offset table overflow", binaryPlistData(nil, 1, 1, 1, 0, maxUint64)},
+		{"offset table size overflow", binaryPlistData(nil, 255, 1, maxUint64/128, 0, 8)},
+		{"offset table truncated", binaryPlistData(nil, 8, 1, 2, 0, 8)},
+		{"object offset overflow", binaryPlistData([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 8, 1, 1, 0, 8)},
+		{"unknown top object", binaryPlistData(object(0x09), 1, 1, 1, 5, 9)},
+		{"string overflow", binaryPlistData(object(append([]byte{0x5f}, huge...)...), 1, 1, 1, 0, tableOffset(object(append([]byte{0x5f}, huge...)...)))},
+		{"unicode overflow", binaryPlistData(object(append([]byte{0x6f}, huge...)...), 1, 1, 1, 0, tableOffset(object(append([]byte{0x6f}, huge...)...)))},
+		{"array overflow", binaryPlistData(object(append([]byte{0xaf}, huge...)...), 1, 8, 1, 0, tableOffset(object(append([]byte{0xaf}, huge...)...)))},
+		{"dict overflow", binaryPlistData(object(append([]byte{0xdf}, huge...)...), 1, 8, 1, 0, tableOffset(object(append([]byte{0xdf}, huge...)...)))},
+		{"self reference", binaryPlistData(object(0xa1, 0), 1, 1, 1, 0, 10)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodePlist(tt.data); err == nil {
+				t.Error("decodePlist() expected error")
+			}
+		})
+	}
+}
+
+func TestDecodeBinaryPlistSharedReferences(t *testing.T) {
+	// arrays that reference the next array twice, the last one is empty
+	const arrays = 40
+	var body []byte
+	var offsets []byte
+	for i := 0; i < arrays; i++ {
+		offsets = append(offsets, byte(8+len(body)))
+		if i == arrays-1 {
+			body = append(body, 0xa0)
+			continue
+		}
+		body = append(body, 0xa2, byte(i+1), byte(i+1))
+	}
+	tableOffset := uint64(8 + len(body))
+	data := binaryPlistData(append(body, offsets...), 1, 1, arrays, 0, tableOffset)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := decodePlist(data)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("decodePlist() did not finish")
+	}
+
+	// the same arrays with a reference from the last to the first array
+	body[len(body)-1] = 0xa1
+	cycle := binaryPlistData(append(append(body, 0), offsets...), 1, 1, arrays, 0, tableOffset+1)
+	if _, err := decodePlist(cycle); err == nil {
+		t.Error("decodePlist() expected error for reference cycle")
+	}
+}
+
+func TestDecodeBinaryPlistTruncated(t *testing.T) {
+	alice, err := base64.StdEncoding.DecodeString(alicePlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decodePlist(alice); err != nil {
+		t.Fatal(err)
+	}
+	for i := len("bplist00"); i < len(alice); i++ {
+		// must not panic, the result is irrelevant
+		decodePlist(alice[:i]) // nolint:errcheck
+	}
+}
+
+func FuzzDecodePlist(f *testing.F) {
+	alice, err := base64.StdEncoding.DecodeString(alicePlist)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(alice)
+	f.Add([]byte(rootPlist))
+	f.Add(binaryPlistData(nil, 1, 1, 1, 0, ^uint64(0)))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		decodePlist(data) // nolint:errcheck
+	})
+}
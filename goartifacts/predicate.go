@@ -0,0 +1,119 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+// SourcePredicate decides whether a source of an artifact definition is
+// kept. Custom predicates can be combined with the predicates of this
+// package.
+type SourcePredicate func(artifactDefinition ArtifactDefinition, source Source) bool
+
+// SourceOS keeps sources where both the artifact definition and the source
+// support the operating system.
+func SourceOS(operatingSystem string) SourcePredicate {
+	return func(artifactDefinition ArtifactDefinition, source Source) bool {
+		return IsOSArtifactDefinition(operatingSystem, artifactDefinition.SupportedOs) &&
+			IsOSArtifactDefinition(operatingSystem, source.SupportedOs)
+	}
+}
+
+// SourceTypes keeps sources of the given types, e.g. SourceType.RegistryKey.
+func SourceTypes(sourceTypes ...string) SourcePredicate {
+	return func(_ ArtifactDefinition, source Source) bool {
+		return containsString(sourceTypes, source.Type)
+	}
+}
+
+// SourceConditions keeps sources where evaluate returns true for all
+// conditions of the artifact definition and the source.
+func SourceConditions(evaluate func(condition string) bool) SourcePredicate {
+	return func(artifactDefinition ArtifactDefinition, source Source) bool {
+		for _, condition := range appendString(artifactDefinition.Conditions, source.Conditions...) {
+			if !evaluate(condition) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// SourceLabels keeps sources whose artifact definition labels match the
+// filter.
+func SourceLabels(filter LabelFilter) SourcePredicate {
+	return func(artifactDefinition ArtifactDefinition, _ Source) bool {
+		return filter.Match(artifactDefinition.Labels)
+	}
+}
+
+// AllOf keeps sources that satisfy all predicates.
+func AllOf(predicates ...SourcePredicate) SourcePredicate {
+	return func(artifactDefinition ArtifactDefinition, source Source) bool {
+		for _, predicate := range predicates {
+			if !predicate(artifactDefinition, source) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyOf keeps sources that satisfy at least one of the predicates.
+func AnyOf(predicates ...SourcePredicate) SourcePredicate {
+	return func(artifactDefinition ArtifactDefinition, source Source) bool {
+		for _, predicate := range predicates {
+			if predicate(artifactDefinition, source) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not keeps sources that do not satisfy the predicate.
+func Not(predicate SourcePredicate) SourcePredicate {
+	return func(artifactDefinition ArtifactDefinition, source Source) bool {
+		return !predicate(artifactDefinition, source)
+	}
+}
+
+// FilterSources returns the artifact definitions with the sources that
+// satisfy all predicates. Artifact definitions without remaining sources are
+// removed. Artifact group sources are filtered like all other sources, so
+// the predicates should be applied to selected definitions, e.g. the result
+// of FilterName.
+func FilterSources(artifactDefinitions []ArtifactDefinition, predicates ...SourcePredicate) []ArtifactDefinition {
+	keep := AllOf(predicates...)
+	var filtered []ArtifactDefinition
+	for _, artifactDefinition := range artifactDefinitions {
+		var sources []Source
+		for _, source := range artifactDefinition.Sources {
+			if keep(artifactDefinition, source) {
+				sources = append(sources, source)
+			}
+		}
+		if len(sources) == 0 {
+			continue
+		}
+		artifactDefinition.Sources = sources
+		filtered = append(filtered, artifactDefinition)
+	}
+	return filtered
+}
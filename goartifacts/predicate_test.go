@@ -0,0 +1,92 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFilterSources(t *testing.T) {
+	definitions := []ArtifactDefinition{
+		{Name: "Run", Labels: []string{"Software"}, SupportedOs: []string{"Windows"}, Sources: []Source{
+			{Type: SourceType.RegistryKey},
+			{Type: SourceType.Command, Conditions: []string{"os_major_version >= 10"}},
+		}},
+		{Name: "Processes", Labels: []string{"Processes"}, Sources: []Source{
+			{Type: SourceType.Command, SupportedOs: []string{"Linux"}},
+			{Type: SourceType.Wmi, SupportedOs: []string{"Windows"}},
+		}},
+		{Name: "Hosts", Conditions: []string{"os_major_version >= 6"}, Sources: []Source{
+			{Type: SourceType.File},
+		}},
+	}
+	evaluate := func(condition string) bool { return strings.HasSuffix(condition, ">= 6") }
+	custom := func(artifactDefinition ArtifactDefinition, _ Source) bool {
+		return artifactDefinition.Name != "Hosts"
+	}
+
+	type source struct{ artifact, sourceType string }
+	tests := []struct {
+		name       string
+		predicates []SourcePredicate
+		want       []source
+	}{
+		{"none", nil, []source{
+			{"Run", SourceType.RegistryKey}, {"Run", SourceType.Command},
+			{"Processes", SourceType.Command}, {"Processes", SourceType.Wmi}, {"Hosts", SourceType.File},
+		}},
+		{"os", []SourcePredicate{SourceOS("linux")}, []source{{"Processes", SourceType.Command}, {"Hosts", SourceType.File}}},
+		{"no commands", []SourcePredicate{Not(SourceTypes(SourceType.Command))}, []source{
+			{"Run", SourceType.RegistryKey}, {"Processes", SourceType.Wmi}, {"Hosts", SourceType.File},
+		}},
+		{"registry only", []SourcePredicate{SourceTypes(SourceType.RegistryKey, SourceType.RegistryValue)}, []source{{"Run", SourceType.RegistryKey}}},
+		{"conditions", []SourcePredicate{SourceConditions(evaluate)}, []source{
+			{"Run", SourceType.RegistryKey}, {"Processes", SourceType.Command}, {"Processes", SourceType.Wmi}, {"Hosts", SourceType.File},
+		}},
+		{"labels", []SourcePredicate{SourceLabels(LabelFilter{None: []string{"Processes"}})}, []source{
+			{"Run", SourceType.RegistryKey}, {"Run", SourceType.Command}, {"Hosts", SourceType.File},
+		}},
+		{"combined", []SourcePredicate{SourceOS("Windows"), AnyOf(SourceTypes(SourceType.Wmi), custom)}, []source{
+			{"Run", SourceType.RegistryKey}, {"Run", SourceType.Command}, {"Processes", SourceType.Wmi},
+		}},
+		{"all of", []SourcePredicate{AllOf(SourceTypes(SourceType.Command), SourceOS("Windows"))}, []source{{"Run", SourceType.Command}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []source
+			for _, artifactDefinition := range FilterSources(definitions, tt.predicates...) {
+				for _, s := range artifactDefinition.Sources {
+					got = append(got, source{artifactDefinition.Name, s.Type})
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterSources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if len(definitions[1].Sources) != 2 {
+		t.Error("FilterSources() modified the definitions")
+	}
+}
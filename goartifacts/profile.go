@@ -0,0 +1,256 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// A Profile is a named collection configuration. Profiles are YAML
+// documents like artifact definitions:
+//
+//	name: WindowsTriage
+//	doc: Fast triage of Windows systems.
+//	inherits: [Base]
+//	artifacts: [WindowsEventLogs, BrowserHistory]
+//	queries: ['label:Browser and not type:COMMAND']
+//	os: [Windows]
+//	exclude: ['C:\pagefile.sys']
+//	limits:
+//	  max_size: 10737418240
+//	  max_duration: 2h
+//	filter:
+//	  max_size: 1073741824
+type Profile struct {
+	Name string `yaml:"name"`
+	Doc  string `yaml:"doc,omitempty"`
+	// Inherits lists profiles whose settings are merged before the
+	// settings of this profile.
+	Inherits []string `yaml:"inherits,omitempty"`
+	// Artifacts lists names of artifacts and artifact groups.
+	Artifacts []string `yaml:"artifacts,omitempty"`
+	// Queries select additional artifacts, see Query.
+	Queries []string `yaml:"queries,omitempty"`
+	// OS lists the operating systems the profile can be used for, all if
+	// empty.
+	OS []string `yaml:"os,omitempty"`
+	// Exclude lists patterns that are never collected, see
+	// ExpandOptions.Exclude.
+	Exclude []string    `yaml:"exclude,omitempty"`
+	Limits  Limits      `yaml:"limits,omitempty"`
+	Filter  *FileFilter `yaml:"filter,omitempty"`
+}
+
+// Limits of a collection. Zero values are not limited.
+type Limits struct {
+	MaxItems    int           `yaml:"max_items,omitempty"`
+	MaxSize     int64         `yaml:"max_size,omitempty"`
+	MaxDuration time.Duration `yaml:"max_duration,omitempty"`
+}
+
+// Budget returns the limits as a budget for EstimateReport.Check.
+func (l Limits) Budget() Budget {
+	return Budget{MaxItems: l.MaxItems, MaxSize: l.MaxSize, MaxDuration: l.MaxDuration}
+}
+
+// DecodeProfiles decodes all profiles of a YAML stream.
+func DecodeProfiles(r io.Reader) ([]Profile, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.SetStrict(true)
+
+	var profiles []Profile
+	for {
+		profile := Profile{}
+		if err := decoder.Decode(&profile); err != nil {
+			if err == io.EOF {
+				return profiles, nil
+			}
+			return profiles, err
+		}
+		profiles = append(profiles, profile)
+	}
+}
+
+// LoadProfiles decodes the profiles of a file.
+func LoadProfiles(filename string) ([]Profile, error) {
+	f, err := os.Open(filename) // #nosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeProfiles(f)
+}
+
+// MergeProfiles merges profiles in order. Lists are combined without
+// duplicates, limits and the filter of later profiles replace those of
+// earlier ones if they are set. The operating systems of later profiles
+// replace those of earlier ones as well, so a profile can narrow the
+// operating systems of the profiles it inherits from. Name, documentation
+// and inheritance are taken from the last profile.
+func MergeProfiles(profiles ...Profile) Profile {
+	var merged Profile
+	for _, profile := range profiles {
+		merged.Name, merged.Doc, merged.Inherits = profile.Name, profile.Doc, profile.Inherits
+		merged.Artifacts = mergeStrings(merged.Artifacts, profile.Artifacts)
+		merged.Queries = mergeStrings(merged.Queries, profile.Queries)
+		if len(profile.OS) > 0 {
+			merged.OS = mergeStrings(nil, profile.OS)
+		}
+		merged.Exclude = mergeStrings(merged.Exclude, profile.Exclude)
+		if profile.Limits.MaxItems != 0 {
+			merged.Limits.MaxItems = profile.Limits.MaxItems
+		}
+		if profile.Limits.MaxSize != 0 {
+			merged.Limits.MaxSize = profile.Limits.MaxSize
+		}
+		if profile.Limits.MaxDuration != 0 {
+			merged.Limits.MaxDuration = profile.Limits.MaxDuration
+		}
+		if profile.Filter != nil {
+			merged.Filter = profile.Filter
+		}
+	}
+	return merged
+}
+
+// ResolveProfile returns the profile with the name, merged with all
+// profiles it inherits from.
+func ResolveProfile(name string, profiles []Profile) (Profile, error) {
+	profileMap := map[string]Profile{}
+	for _, profile := range profiles {
+		if _, ok := profileMap[profile.Name]; ok {
+			return Profile{}, fmt.Errorf("duplicate profile %s", profile.Name)
+		}
+		profileMap[profile.Name] = profile
+	}
+
+	var resolve func(name string, chain []string) (Profile, error)
+	resolve = func(name string, chain []string) (Profile, error) {
+		if containsString(chain, name) {
+			return Profile{}, fmt.Errorf("profile inheritance cycle %s", strings.Join(append(chain, name), " -> "))
+		}
+		profile, ok := profileMap[name]
+		if !ok {
+			if len(chain) > 0 {
+				return Profile{}, fmt.Errorf("unknown profile %s inherited by %s", name, chain[len(chain)-1])
+			}
+			return Profile{}, fmt.Errorf("unknown profile %s", name)
+		}
+
+		var parents []Profile
+		for _, parent := range profile.Inherits {
+			resolved, err := resolve(parent, appendString(chain, name))
+			if err != nil {
+				return Profile{}, err
+			}
+			parents = append(parents, resolved)
+		}
+		merged := MergeProfiles(append(parents, profile)...)
+		merged.Inherits = nil
+		return merged, nil
+	}
+	return resolve(name, nil)
+}
+
+// Validate checks that all artifact names of the profile are defined and
+// queries, exclusions and the filter are valid. Unknown names are returned
+// as *UnknownNamesError.
+func (p Profile) Validate(artifactDefinitions []ArtifactDefinition) error {
+	if p.Name == "" {
+		return fmt.Errorf("profile without name")
+	}
+	names := map[string]bool{}
+	for _, artifactDefinition := range artifactDefinitions {
+		names[artifactDefinition.Name] = true
+	}
+	var unknown []string
+	for _, name := range p.Artifacts {
+		if !names[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return newUnknownNamesError(unknown, artifactDefinitions)
+	}
+
+	for _, query := range p.Queries {
+		if _, err := ParseQuery(query); err != nil {
+			return err
+		}
+	}
+	if err := p.ExpandOptions().Validate(); err != nil {
+		return err
+	}
+	if p.Filter != nil {
+		return p.Filter.Validate()
+	}
+	return nil
+}
+
+// ExpandOptions returns the expand options of the profile.
+func (p Profile) ExpandOptions() ExpandOptions {
+	return ExpandOptions{Exclude: p.Exclude}
+}
+
+// Select returns the artifacts of the profile for an operating system in
+// selection order. Artifact names are followed by the artifacts matching
+// the queries, artifact groups are resolved.
+func (p Profile) Select(artifactDefinitions []ArtifactDefinition, operatingSystem string) ([]ArtifactDefinition, error) {
+	if len(p.OS) > 0 && !IsOSArtifactDefinition(operatingSystem, p.OS) {
+		return nil, fmt.Errorf("profile %s does not support %s", p.Name, operatingSystem)
+	}
+
+	names := appendString(nil, p.Artifacts...)
+	for _, query := range p.Queries {
+		matching, err := SelectQuery(query, artifactDefinitions, QueryOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, artifactDefinition := range matching {
+			names = append(names, artifactDefinition.Name)
+		}
+	}
+
+	selected, err := SelectArtifacts(names, artifactDefinitions, SelectOptions{OS: operatingSystem})
+	var artifacts []ArtifactDefinition
+	for _, artifact := range selected {
+		artifacts = append(artifacts, artifact.Definition)
+	}
+	return artifacts, err
+}
+
+// mergeStrings appends all elements of b to a that are not contained yet.
+func mergeStrings(a, b []string) []string {
+	var merged []string
+	for _, s := range append(a[:len(a):len(a)], b...) {
+		if !containsString(merged, s) {
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
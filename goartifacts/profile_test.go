@@ -0,0 +1,118 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveProfile(t *testing.T) {
+	profiles, err := LoadProfiles("../test/profiles/profiles.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 6 {
+		t.Fatalf("LoadProfiles() = %d profiles, want 6", len(profiles))
+	}
+
+	triage, err := ResolveProfile("Triage", profiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Profile{
+		Name:      "Triage",
+		Doc:       "Fast triage",
+		Artifacts: []string{"C"},
+		Queries:   []string{"name:Nested"},
+		OS:        []string{"Linux", "Windows"},
+		Exclude:   []string{"/dir/a/**"},
+		Limits:    Limits{MaxItems: 10, MaxDuration: time.Hour},
+		Filter:    &FileFilter{MaxSize: 1024, Modified: TimeWindow{After: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+	if !reflect.DeepEqual(triage, want) {
+		t.Errorf("ResolveProfile() = %#v, want %#v", triage, want)
+	}
+
+	definitions := getRunDefinitions()
+	if err := triage.Validate(definitions); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	selected, err := triage.Select(definitions, "Linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, artifactDefinition := range selected {
+		names = append(names, artifactDefinition.Name)
+	}
+	if want := []string{"C", "B", "A"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Select() = %v, want %v", names, want)
+	}
+	if _, err := triage.Select(definitions, "Darwin"); err == nil {
+		t.Error("Select() for unsupported OS succeeded")
+	}
+
+	for name, wantErr := range map[string]string{"Loop1": "cycle", "Orphan": "unknown profile Missing", "Unknown": "unknown profile"} {
+		if _, err := ResolveProfile(name, profiles); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("ResolveProfile(%s) error = %v, want %s", name, err, wantErr)
+		}
+	}
+
+	broken, err := ResolveProfile("Broken", profiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknownErr, ok := broken.Validate(definitions).(*UnknownNamesError)
+	if !ok || !reflect.DeepEqual(unknownErr.Names, []string{"Unknown"}) {
+		t.Errorf("Validate() error = %v", unknownErr)
+	}
+	if err := (Profile{Name: "Invalid", Queries: []string{"foo:bar"}}).Validate(definitions); err == nil {
+		t.Error("Validate() accepted invalid query")
+	}
+}
+
+func TestMergeProfiles(t *testing.T) {
+	merged := MergeProfiles(
+		Profile{Name: "A", Artifacts: []string{"A", "B"}, Limits: Limits{MaxSize: 10, MaxItems: 5}},
+		Profile{Name: "B", Artifacts: []string{"B", "C"}, Limits: Limits{MaxItems: 7}},
+	)
+	want := Profile{Name: "B", Artifacts: []string{"A", "B", "C"}, Limits: Limits{MaxSize: 10, MaxItems: 7}}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("MergeProfiles() = %#v, want %#v", merged, want)
+	}
+
+	merged = MergeProfiles(
+		Profile{Name: "A", OS: []string{"Linux", "Windows"}},
+		Profile{Name: "B"},
+		Profile{Name: "C", OS: []string{"Windows"}},
+	)
+	if want := []string{"Windows"}; !reflect.DeepEqual(merged.OS, want) {
+		t.Errorf("MergeProfiles() OS = %v, want %v", merged.OS, want)
+	}
+	merged = MergeProfiles(Profile{Name: "A", OS: []string{"Linux"}}, Profile{Name: "B"})
+	if want := []string{"Linux"}; !reflect.DeepEqual(merged.OS, want) {
+		t.Errorf("MergeProfiles() OS = %v, want %v", merged.OS, want)
+	}
+}
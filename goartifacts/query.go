@@ -0,0 +1,423 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A Query selects artifact definitions by their fields and sources. Queries
+// consist of terms combined with "and", "or", "not" and parentheses, e.g.
+//
+//	label:Browser and os:Windows and not type:COMMAND
+//	name:Windows*EventLog* or provides:users.sid
+//	path~"\\AppData\\"
+//
+// A term is a field, an operator and a value. The operator ":" matches the
+// value as case insensitive glob with "*" and "?", the operator "~" checks
+// if the field contains the value, ignoring case. Values can be quoted with
+// double quotes and then use Go escape sequences. A term matches an
+// artifact definition if any value of the field matches. The fields are
+// listed in QueryFields.
+type Query struct {
+	query string
+	root  queryNode
+}
+
+// QueryFields maps the fields of the query language to their descriptions.
+var QueryFields = map[string]string{
+	"name":      "name of the artifact definition",
+	"doc":       "documentation of the artifact definition",
+	"label":     "labels of the artifact definition",
+	"os":        "operating systems, artifact definitions without supported_os match all",
+	"provides":  "knowledge base keys provided by the artifact definition or its sources",
+	"url":       "urls of the artifact definition",
+	"type":      "types of the sources",
+	"path":      "paths of FILE, DIRECTORY and PATH sources",
+	"key":       "keys of REGISTRY_KEY and REGISTRY_VALUE sources",
+	"value":     "value names of REGISTRY_VALUE sources",
+	"cmd":       "command and arguments of COMMAND sources",
+	"query":     "queries of WMI sources",
+	"member":    "names in ARTIFACT_GROUP sources",
+	"condition": "conditions of the artifact definition or its sources",
+}
+
+// QueryError is returned for invalid queries.
+type QueryError struct {
+	Query    string
+	Position int
+	Message  string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid query %q at position %d: %s", e.Query, e.Position, e.Message)
+}
+
+// ParseQuery parses a query.
+func ParseQuery(query string) (*Query, error) {
+	p := &queryParser{query: query}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.query) {
+		return nil, p.errorf("unexpected %q", p.query[p.pos:])
+	}
+	return &Query{query: query, root: root}, nil
+}
+
+// String returns the query as parsed.
+func (q *Query) String() string {
+	return q.query
+}
+
+// Match returns if the artifact definition matches the query.
+func (q *Query) Match(artifactDefinition ArtifactDefinition) bool {
+	return q.root.match(artifactDefinition)
+}
+
+// QueryOptions configure SelectQuery.
+type QueryOptions struct {
+	// ExpandGroups resolves artifact groups of matching definitions, see
+	// SelectArtifacts.
+	ExpandGroups bool
+	// OS is used to resolve artifact groups, see SelectOptions.
+	OS string
+}
+
+// SelectQuery returns all artifact definitions that match the query in the
+// order of the definitions. If groups are expanded, the matching
+// definitions are resolved in this order with SelectArtifacts and an
+// *UnknownNamesError is returned for undefined group members.
+func SelectQuery(query string, artifactDefinitions []ArtifactDefinition, options QueryOptions) ([]ArtifactDefinition, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []ArtifactDefinition
+	var names []string
+	for _, artifactDefinition := range artifactDefinitions {
+		if q.Match(artifactDefinition) {
+			matching = append(matching, artifactDefinition)
+			names = append(names, artifactDefinition.Name)
+		}
+	}
+	if !options.ExpandGroups {
+		return matching, nil
+	}
+
+	selected, err := SelectArtifacts(names, artifactDefinitions, SelectOptions{OS: options.OS})
+	var expanded []ArtifactDefinition
+	for _, artifact := range selected {
+		expanded = append(expanded, artifact.Definition)
+	}
+	return expanded, err
+}
+
+type queryNode interface {
+	match(artifactDefinition ArtifactDefinition) bool
+}
+
+type andNode struct{ left, right queryNode }
+
+func (n *andNode) match(a ArtifactDefinition) bool { return n.left.match(a) && n.right.match(a) }
+
+type orNode struct{ left, right queryNode }
+
+func (n *orNode) match(a ArtifactDefinition) bool { return n.left.match(a) || n.right.match(a) }
+
+type notNode struct{ node queryNode }
+
+func (n *notNode) match(a ArtifactDefinition) bool { return !n.node.match(a) }
+
+type termNode struct {
+	field    string
+	contains bool
+	value    string
+}
+
+func (n *termNode) match(a ArtifactDefinition) bool {
+	if n.field == "os" && len(a.SupportedOs) == 0 {
+		return true
+	}
+	value := strings.ToLower(n.value)
+	for _, s := range queryValues(n.field, a) {
+		s = strings.ToLower(s)
+		if (n.contains && strings.Contains(s, value)) || (!n.contains && matchWildcard(value, s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryValues returns all values of a field of an artifact definition.
+func queryValues(field string, a ArtifactDefinition) []string { // nolint:gocyclo
+	var values []string
+	switch field {
+	case "name":
+		return []string{a.Name}
+	case "doc":
+		return []string{a.Doc}
+	case "label":
+		return a.Labels
+	case "os":
+		return a.SupportedOs
+	case "url":
+		return a.Urls
+	case "provides":
+		values = appendString(values, a.Provides...)
+	case "condition":
+		values = appendString(values, a.Conditions...)
+	}
+	for _, source := range a.Sources {
+		switch field {
+		case "type":
+			values = append(values, source.Type)
+		case "path":
+			values = append(values, source.Attributes.Paths...)
+		case "key":
+			values = append(values, source.Attributes.Keys...)
+			for _, pair := range source.Attributes.KeyValuePairs {
+				values = append(values, pair.Key)
+			}
+		case "value":
+			for _, pair := range source.Attributes.KeyValuePairs {
+				values = append(values, pair.Value)
+			}
+		case "cmd":
+			if source.Attributes.Cmd != "" {
+				values = append(values, strings.Join(append([]string{source.Attributes.Cmd}, source.Attributes.Args...), " "))
+			}
+		case "query":
+			if source.Attributes.Query != "" {
+				values = append(values, source.Attributes.Query)
+			}
+		case "member":
+			values = append(values, source.Attributes.Names...)
+		case "provides":
+			for _, provide := range source.Provides {
+				values = append(values, provide.Key)
+			}
+		case "condition":
+			values = append(values, source.Conditions...)
+		}
+	}
+	return values
+}
+
+// matchWildcard matches s against a pattern with "*" and "?".
+func matchWildcard(pattern, s string) bool {
+	p, t := []rune(pattern), []rune(s)
+	star, match := -1, 0
+	i, j := 0, 0
+	for j < len(t) {
+		switch {
+		case i < len(p) && (p[i] == '?' || p[i] == t[j]):
+			i++
+			j++
+		case i < len(p) && p[i] == '*':
+			star, match = i, j
+			i++
+		case star >= 0:
+			i = star + 1
+			match++
+			j = match
+		default:
+			return false
+		}
+	}
+	for i < len(p) && p[i] == '*' {
+		i++
+	}
+	return i == len(p)
+}
+
+// queryParser is a recursive descent parser for queries:
+//
+//	or   = and { "or" and }
+//	and  = not { "and" not }
+//	not  = "not" not | "(" or ")" | term
+//	term = field ( ":" | "~" ) value
+type queryParser struct {
+	query string
+	pos   int
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return &QueryError{Query: p.query, Position: p.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *queryParser) skipSpace() {
+	for p.pos < len(p.query) && unicode.IsSpace(rune(p.query[p.pos])) {
+		p.pos++
+	}
+}
+
+// keyword consumes the keyword if it is next.
+func (p *queryParser) keyword(keyword string) bool {
+	p.skipSpace()
+	end := p.pos + len(keyword)
+	if end > len(p.query) || !strings.EqualFold(p.query[p.pos:end], keyword) {
+		return false
+	}
+	if end < len(p.query) && !unicode.IsSpace(rune(p.query[end])) && p.query[end] != '(' {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.keyword("not") {
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{node}, nil
+	}
+	p.skipSpace()
+	if p.pos < len(p.query) && p.query[p.pos] == '(' {
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.query) || p.query[p.pos] != ')' {
+			return nil, p.errorf("missing )")
+		}
+		p.pos++
+		return node, nil
+	}
+	return p.parseTerm()
+}
+
+func (p *queryParser) parseTerm() (queryNode, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.query) && isFieldChar(p.query[p.pos]) {
+		p.pos++
+	}
+	field := strings.ToLower(p.query[start:p.pos])
+	if field == "" {
+		if p.pos >= len(p.query) {
+			return nil, p.errorf("unexpected end of query")
+		}
+		return nil, p.errorf("expected field")
+	}
+	if _, ok := QueryFields[field]; !ok {
+		p.pos = start
+		return nil, p.errorf("unknown field %s, use one of %s", field, strings.Join(queryFieldNames(), ", "))
+	}
+
+	if p.pos >= len(p.query) || (p.query[p.pos] != ':' && p.query[p.pos] != '~') {
+		return nil, p.errorf("expected : or ~ after %s", field)
+	}
+	term := &termNode{field: field, contains: p.query[p.pos] == '~'}
+	p.pos++
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	term.value = value
+	return term, nil
+}
+
+func (p *queryParser) parseValue() (string, error) {
+	start := p.pos
+	if p.pos < len(p.query) && p.query[p.pos] == '"' {
+		for p.pos++; p.pos < len(p.query) && p.query[p.pos] != '"'; p.pos++ {
+			if p.query[p.pos] == '\\' {
+				p.pos++
+			}
+		}
+		if p.pos >= len(p.query) {
+			p.pos = start
+			return "", p.errorf("unterminated string")
+		}
+		p.pos++
+		value, err := strconv.Unquote(p.query[start:p.pos])
+		if err != nil {
+			p.pos = start
+			return "", p.errorf("invalid string: %s", err)
+		}
+		return value, nil
+	}
+
+	for p.pos < len(p.query) && !unicode.IsSpace(rune(p.query[p.pos])) && p.query[p.pos] != '(' && p.query[p.pos] != ')' {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected value")
+	}
+	return p.query[start:p.pos], nil
+}
+
+func isFieldChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_'
+}
+
+func queryFieldNames() []string {
+	var names []string
+	for name := range QueryFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func getQueryDefinitions() []ArtifactDefinition {
+	return []ArtifactDefinition{
+		{Name: "ChromeHistory", Labels: []string{"Browser"}, SupportedOs: []string{"Windows", "Linux"}, Sources: []Source{
+			{Type: SourceType.File, Attributes: Attributes{Paths: []string{`%%users.localappdata%%\Google\Chrome\User Data\*\History`}, Separator: `\`}},
+		}},
+		{Name: "WindowsEventLogs", Labels: []string{"Logs"}, SupportedOs: []string{"Windows"}, Sources: []Source{
+			{Type: SourceType.File, Attributes: Attributes{Paths: []string{`%%environ_systemroot%%\System32\winevt\Logs\*.evtx`}}},
+		}},
+		{Name: "WindowsSystemEventLog", Labels: []string{"Logs"}, SupportedOs: []string{"Windows"}, Sources: []Source{
+			{Type: SourceType.File, Attributes: Attributes{Paths: []string{`%%environ_systemroot%%\System32\winevt\Logs\System.evtx`}}},
+		}},
+		{Name: "WindowsUsers", SupportedOs: []string{"Windows"}, Provides: []string{"users.sid"}, Sources: []Source{
+			{Type: SourceType.RegistryKey, Attributes: Attributes{Keys: []string{`HKEY_LOCAL_MACHINE\Software\Microsoft\Windows NT\CurrentVersion\ProfileList\*`}}},
+		}},
+		{Name: "BrowserCommand", Labels: []string{"Browser"}, Sources: []Source{
+			{Type: SourceType.Command, Attributes: Attributes{Cmd: "browser", Args: []string{"--list"}}},
+		}},
+		{Name: "Browsers", Sources: []Source{
+			{Type: SourceType.ArtifactGroup, Attributes: Attributes{Names: []string{"ChromeHistory", "BrowserCommand"}}},
+		}},
+	}
+}
+
+func TestSelectQuery(t *testing.T) {
+	tests := []struct {
+		query        string
+		expandGroups bool
+		want         []string
+	}{
+		{"label:Browser and os:Windows and not type:COMMAND", false, []string{"ChromeHistory"}},
+		{"label:browser AND os:windows", false, []string{"ChromeHistory", "BrowserCommand"}},
+		{"name:Windows*EventLog*", false, []string{"WindowsEventLogs", "WindowsSystemEventLog"}},
+		{"provides:users.sid", false, []string{"WindowsUsers"}},
+		{`path~"\\AppData\\" or path~"\\Google\\"`, false, []string{"ChromeHistory"}},
+		{"not (label:Logs or label:Browser) and not member:*", false, []string{"WindowsUsers"}},
+		{`cmd:"browser --list"`, false, []string{"BrowserCommand"}},
+		{"name:Browsers", false, []string{"Browsers"}},
+		{"name:Browsers", true, []string{"ChromeHistory", "BrowserCommand"}},
+		{"name:WindowsEventLog", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := SelectQuery(tt.query, getQueryDefinitions(), QueryOptions{ExpandGroups: tt.expandGroups, OS: "Windows"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, artifactDefinition := range got {
+				names = append(names, artifactDefinition.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("SelectQuery() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, query := range []string{"", "label", "label:", "foo:bar", "(label:x", "label:x)", `path~"\\AppData`, "label:x and", "not"} {
+		if _, err := ParseQuery(query); err == nil {
+			t.Errorf("ParseQuery(%q) succeeded", query)
+		} else if _, ok := err.(*QueryError); !ok {
+			t.Errorf("ParseQuery(%q) error = %v, want QueryError", query, err)
+		}
+	}
+}
+
+func Test_matchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "", true},
+		{"a*c", "abbc", true},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"*eventlog*", "windowseventlogs", true},
+		{"*log", "logs", false},
+	}
+	for _, tt := range tests {
+		if got := matchWildcard(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("matchWildcard(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// ErrUnknownParameter is returned by a ParameterResolver that does not
+// provide a parameter.
+var ErrUnknownParameter = errors.New("unknown parameter")
+
+// ParameterResolver resolves knowledge base parameters like users.homedir
+// into their values. It can be used to implement ArtifactCollector.Resolve.
+type ParameterResolver interface {
+	Resolve(parameter string) ([]string, error)
+}
+
+// Resolvers combines multiple resolvers. Resolve returns the result of the
+// first resolver that does not return ErrUnknownParameter.
+type Resolvers []ParameterResolver
+
+// Resolve resolves the parameter with the first resolver that provides it.
+func (r Resolvers) Resolve(parameter string) ([]string, error) {
+	for _, resolver := range r {
+		values, err := resolver.Resolve(parameter)
+		if err == ErrUnknownParameter {
+			continue
+		}
+		return values, err
+	}
+	return nil, ErrUnknownParameter
+}
+
+// RegistryValueFS is implemented by registry file systems that can read
+// values in addition to keys.
+type RegistryValueFS interface {
+	fs.FS
+	ReadValue(key, name string) (string, error)
+}
+
+// readRegistryValue reads a string value from a registry file system. If the
+// file system does not implement RegistryValueFS, values are expected as
+// files in the key directory, e.g. for extracted registry hives.
+func readRegistryValue(registry fs.FS, key, name string) (string, error) {
+	if valueFS, ok := registry.(RegistryValueFS); ok {
+		return valueFS.ReadValue(key, name)
+	}
+	f, err := registry.Open(path.Join(key, name))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\x00\r\n"), nil
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Hashes of a collected item, hex encoded.
+type Hashes struct {
+	MD5    string `json:"md5,omitempty"`
+	SHA1   string `json:"sha1,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// FileResult is a collected file.
+type FileResult struct {
+	Path     string     `json:"path"`
+	Size     int64      `json:"size"`
+	Modified time.Time  `json:"modified"`
+	Accessed *time.Time `json:"accessed,omitempty"`
+	Changed  *time.Time `json:"changed,omitempty"`
+	Created  *time.Time `json:"created,omitempty"`
+	Hashes   Hashes     `json:"hashes"`
+	// Export is the location of the file content in the collection output.
+	Export string `json:"export,omitempty"`
+}
+
+// DirectoryEntry is a single entry of a DirectoryResult.
+type DirectoryEntry struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Mode     string    `json:"mode"`
+	Modified time.Time `json:"modified"`
+	IsDir    bool      `json:"is_dir,omitempty"`
+}
+
+// DirectoryResult is a collected directory listing.
+type DirectoryResult struct {
+	Path    string           `json:"path"`
+	Entries []DirectoryEntry `json:"entries"`
+}
+
+// PathResult is a collected path.
+type PathResult struct {
+	Path     string    `json:"path"`
+	Exists   bool      `json:"exists"`
+	IsDir    bool      `json:"is_dir,omitempty"`
+	Modified time.Time `json:"modified"`
+}
+
+// RegistryValueType is an enumeration of registry value types.
+var RegistryValueType = struct {
+	String               string
+	ExpandString         string
+	MultiString          string
+	DWord                string
+	QWord                string
+	Binary               string
+	None                 string
+	ResourceList         string
+	FullResource         string
+	ResourceRequirements string
+}{
+	String:               "REG_SZ",
+	ExpandString:         "REG_EXPAND_SZ",
+	MultiString:          "REG_MULTI_SZ",
+	DWord:                "REG_DWORD",
+	QWord:                "REG_QWORD",
+	Binary:               "REG_BINARY",
+	None:                 "REG_NONE",
+	ResourceList:         "REG_RESOURCE_LIST",
+	FullResource:         "REG_FULL_RESOURCE_DESCRIPTOR",
+	ResourceRequirements: "REG_RESOURCE_REQUIREMENTS_LIST",
+}
+
+// RegistryValue is a typed registry value. Depending on the type either
+// String, Strings, Integer or Binary is set.
+type RegistryValue struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	String  string   `json:"string,omitempty"`
+	Strings []string `json:"strings,omitempty"`
+	Integer uint64   `json:"integer,omitempty"`
+	Binary  []byte   `json:"binary,omitempty"`
+}
+
+// RegistryKeyResult is a collected registry key with its values.
+type RegistryKeyResult struct {
+	Key         string          `json:"key"`
+	Modified    time.Time       `json:"modified"`
+	SubKeyCount int             `json:"sub_key_count"`
+	Values      []RegistryValue `json:"values,omitempty"`
+}
+
+// RegistryValueResult is a single value collected for a REGISTRY_VALUE
+// source. Modified is the last write time of the key.
+type RegistryValueResult struct {
+	Key      string        `json:"key"`
+	Modified time.Time     `json:"modified"`
+	Value    RegistryValue `json:"value"`
+}
+
+// CommandResult is the result of an executed command. Stdout and Stderr
+// reference the location of the output in the collection output.
+type CommandResult struct {
+	Argv     []string      `json:"argv"`
+	ExitCode int           `json:"exit_code"`
+	Stdout   string        `json:"stdout,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// WMIResult is the result of a WMI query.
+type WMIResult struct {
+	Query      string                   `json:"query"`
+	BaseObject string                   `json:"base_object,omitempty"`
+	Rows       []map[string]interface{} `json:"rows"`
+}
+
+// Record is the envelope of a single collected item.
+type Record struct {
+	Artifact string `json:"artifact"`
+	// SourceIndex is the index of the source in the artifact definition.
+	SourceIndex int    `json:"source_index"`
+	Type        string `json:"type"`
+	// Pattern is the expanded path, key or command that produced the item.
+	Pattern string `json:"pattern,omitempty"`
+	// Data is one of *FileResult, *DirectoryResult, *PathResult,
+	// *RegistryKeyResult, *RegistryValueResult, *CommandResult or
+	// *WMIResult.
+	Data interface{} `json:"data"`
+}
+
+// NewRecord creates a Record for a result. The type of the record is
+// derived from the type of the result.
+func NewRecord(artifact string, sourceIndex int, pattern string, data interface{}) (Record, error) {
+	record := Record{Artifact: artifact, SourceIndex: sourceIndex, Pattern: pattern, Data: data}
+	switch data.(type) {
+	case *FileResult:
+		record.Type = SourceType.File
+	case *DirectoryResult:
+		record.Type = SourceType.Directory
+	case *PathResult:
+		record.Type = SourceType.Path
+	case *RegistryKeyResult:
+		record.Type = SourceType.RegistryKey
+	case *RegistryValueResult:
+		record.Type = SourceType.RegistryValue
+	case *CommandResult:
+		record.Type = SourceType.Command
+	case *WMIResult:
+		record.Type = SourceType.Wmi
+	default:
+		return record, fmt.Errorf("unsupported result type %T", data)
+	}
+	return record, nil
+}
+
+// UnmarshalJSON decodes a Record and its data according to the type.
+func (r *Record) UnmarshalJSON(b []byte) error {
+	type record Record
+	var raw struct {
+		record
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	var data interface{}
+	switch raw.Type {
+	case SourceType.File:
+		data = &FileResult{}
+	case SourceType.Directory:
+		data = &DirectoryResult{}
+	case SourceType.Path:
+		data = &PathResult{}
+	case SourceType.RegistryKey:
+		data = &RegistryKeyResult{}
+	case SourceType.RegistryValue:
+		data = &RegistryValueResult{}
+	case SourceType.Command:
+		data = &CommandResult{}
+	case SourceType.Wmi:
+		data = &WMIResult{}
+	default:
+		return fmt.Errorf("unsupported record type %s", raw.Type)
+	}
+	if len(raw.Data) > 0 && string(raw.Data) != "null" {
+		if err := json.Unmarshal(raw.Data, data); err != nil {
+			return err
+		}
+	}
+
+	*r = Record(raw.record)
+	r.Data = data
+	return nil
+}
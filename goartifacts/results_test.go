@@ -0,0 +1,87 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordJSON(t *testing.T) {
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name     string
+		data     interface{}
+		wantType string
+	}{
+		{"file", &FileResult{Path: "/foo.bin", Size: 3, Modified: modified, Created: &modified, Hashes: Hashes{MD5: "acbd18db4cc2f85cedef654fccc4a4d8"}}, SourceType.File},
+		{"directory", &DirectoryResult{Path: "/dir", Entries: []DirectoryEntry{{Name: "a", IsDir: true, Mode: "drwxr-xr-x", Modified: modified}}}, SourceType.Directory},
+		{"path", &PathResult{Path: "/dir", Exists: true, IsDir: true, Modified: modified}, SourceType.Path},
+		{"key", &RegistryKeyResult{Key: `HKEY_LOCAL_MACHINE\SOFTWARE`, Modified: modified, SubKeyCount: 2}, SourceType.RegistryKey},
+		{"key with values", &RegistryKeyResult{Key: `HKEY_LOCAL_MACHINE\SOFTWARE`, Modified: modified, Values: []RegistryValue{
+			{Name: "a", Type: RegistryValueType.String, String: "foo"},
+			{Name: "b", Type: RegistryValueType.MultiString, Strings: []string{"foo", "bar"}},
+			{Name: "c", Type: RegistryValueType.QWord, Integer: 1 << 40},
+			{Name: "d", Type: RegistryValueType.Binary, Binary: []byte{0, 1, 2}},
+		}}, SourceType.RegistryKey},
+		{"value", &RegistryValueResult{Key: `HKEY_LOCAL_MACHINE\SOFTWARE`, Modified: modified, Value: RegistryValue{
+			Name: "a", Type: RegistryValueType.DWord, Integer: 1,
+		}}, SourceType.RegistryValue},
+		{"command", &CommandResult{Argv: []string{"ls", "-l"}, ExitCode: 1, Stdout: "ls/stdout", Start: modified, Duration: time.Second}, SourceType.Command},
+		{"wmi", &WMIResult{Query: "SELECT * FROM Win32_Service", Rows: []map[string]interface{}{{"Name": "foo", "Started": true}}}, SourceType.Wmi},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := NewRecord("Artifact", 1, "/pattern", tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if record.Type != tt.wantType {
+				t.Errorf("NewRecord() type = %s, want %s", record.Type, tt.wantType)
+			}
+
+			b, err := json.Marshal(Result{Records: []Record{record}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result Result
+			if err := json.Unmarshal(b, &result); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result.Records, []Record{record}) {
+				t.Errorf("json round trip = %#v, want %#v", result.Records[0], record)
+			}
+		})
+	}
+}
+
+func TestRecordErrors(t *testing.T) {
+	if _, err := NewRecord("Artifact", 0, "", "foo"); err == nil {
+		t.Error("NewRecord() accepted unsupported result")
+	}
+	var record Record
+	if err := json.Unmarshal([]byte(`{"artifact":"Artifact","type":"UNKNOWN"}`), &record); err == nil {
+		t.Error("Unmarshal() accepted unsupported type")
+	}
+}
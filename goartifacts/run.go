@@ -0,0 +1,331 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// Status of an artifact or source in a run.
+type Status string
+
+// Status values.
+const (
+	StatusCollected Status = "collected"
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped"
+	// StatusResumed is used for sources that were completely collected in
+	// an earlier run, see RunOptions.Journal.
+	StatusResumed Status = "resumed"
+)
+
+// RunOptions configure Run.
+type RunOptions struct {
+	// OS is the operating system of the target. If empty runtime.GOOS is
+	// used.
+	OS string
+	// Deduplicate collects every path and registry key only once per run.
+	// The collector receives sources with the expanded paths and keys that
+	// were not requested by earlier sources, RunReport.Items lists all
+	// sources that requested an item.
+	Deduplicate bool
+	// Journal records finished sources and items. Work that is already
+	// recorded in the journal is skipped, so an interrupted run can be
+	// resumed. The journal must be opened for the same definitions.
+	Journal *Journal
+	// FileFilter removes items of FILE sources before they are collected.
+	FileFilter *FileFilter
+	// Dependencies adds the artifacts that provide the parameters of the
+	// selected artifacts and collects them first, see ResolveDependencies.
+	Dependencies bool
+	// Expand configures the expansion of paths and registry keys. If it
+	// or the exclusions of an artifact definition are set, sources are
+	// expanded with ExpandSourceWithOptions before they are collected and
+	// the collector receives the remaining items.
+	Expand ExpandOptions
+}
+
+func (o RunOptions) os() string {
+	if o.OS == "" {
+		return runtime.GOOS
+	}
+	return o.OS
+}
+
+// RunReport is the result of Run.
+type RunReport struct {
+	Start     time.Time        `json:"start"`
+	End       time.Time        `json:"end"`
+	Artifacts []ArtifactReport `json:"artifacts"`
+	Items     []ItemReport     `json:"items,omitempty"`
+	// Error is set if the run could not be started.
+	Error string `json:"error,omitempty"`
+	// MissingParameters lists parameters without providers if
+	// RunOptions.Dependencies is set.
+	MissingParameters []MissingParameter `json:"missing_parameters,omitempty"`
+}
+
+// ArtifactReport is the result of the collection of a single artifact.
+type ArtifactReport struct {
+	Name     string         `json:"name"`
+	Status   Status         `json:"status"`
+	Error    string         `json:"error,omitempty"`
+	Duration time.Duration  `json:"duration"`
+	Sources  []SourceReport `json:"sources,omitempty"`
+}
+
+// SourceReport is the result of the collection of a single source.
+type SourceReport struct {
+	// Index is the index of the source in the artifact definition.
+	Index    int           `json:"index"`
+	Type     string        `json:"type"`
+	Status   Status        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Result   Result        `json:"result"`
+	// Duplicates are items of the source that were already collected for
+	// an earlier source, see RunOptions.Deduplicate.
+	Duplicates []string `json:"duplicates,omitempty"`
+	// Resumed are items of the source that were already collected in an
+	// earlier run, see RunOptions.Journal.
+	Resumed []string `json:"resumed,omitempty"`
+	// Filtered are items of the source that were removed by
+	// RunOptions.FileFilter.
+	Filtered []FilteredItem `json:"filtered,omitempty"`
+	// Excluded are paths and keys of the source that were removed by the
+	// exclusions of RunOptions.Expand or the artifact definition.
+	Excluded []ExcludedPath `json:"excluded,omitempty"`
+}
+
+// Failed returns all artifacts that failed.
+func (r *RunReport) Failed() []ArtifactReport {
+	var failed []ArtifactReport
+	for _, artifact := range r.Artifacts {
+		if artifact.Status == StatusFailed {
+			failed = append(failed, artifact)
+		}
+	}
+	return failed
+}
+
+// Run collects the artifacts with the given names. Artifact groups are
+// resolved and artifacts or sources that are not supported on the target
+// operating system are skipped. Artifacts are collected in the order they
+// are selected and their sources in the order of the artifact definition.
+// Collect is called once for every source.
+func Run(artifactDefinitions []ArtifactDefinition, names []string, collector ArtifactCollector, options RunOptions) *RunReport {
+	collectSource := func(name string, _ int, source Source) (Result, error) {
+		collector.Collect(name, source)
+		return Result{}, nil
+	}
+	return run(artifactDefinitions, names, collector, collectSource, nil, options)
+}
+
+// collectFunc collects a single source. index is the index of the source in
+// the artifact definition.
+type collectFunc func(name string, index int, source Source) (Result, error)
+
+// run implements Run for all collector versions. If cancelled returns an
+// error, all remaining sources are skipped.
+func run(artifactDefinitions []ArtifactDefinition, names []string, target ExpansionTarget, collectSource collectFunc, cancelled func() error, options RunOptions) *RunReport {
+	report := &RunReport{Start: time.Now().UTC()}
+	r := &runner{
+		target: target, collectSource: collectSource, cancelled: cancelled,
+		os: options.os(), journal: options.Journal, fileFilter: options.FileFilter,
+		expand: options.Expand,
+	}
+
+	if options.Journal != nil {
+		if err := options.Journal.check(artifactDefinitions); err != nil {
+			report.Error = err.Error()
+			report.End = time.Now().UTC()
+			return report
+		}
+	}
+	if err := options.Expand.Validate(); err != nil {
+		report.Error = err.Error()
+		report.End = time.Now().UTC()
+		return report
+	}
+	if options.FileFilter != nil {
+		if err := options.FileFilter.Validate(); err != nil {
+			report.Error = err.Error()
+			report.End = time.Now().UTC()
+			return report
+		}
+	}
+	if options.Deduplicate {
+		r.dedup = newDeduplicator(report)
+	}
+
+	selected, unknown := selectArtifacts(names, artifactDefinitions, r.os)
+	if options.Dependencies {
+		dependencies := ResolveDependencies(selected, artifactDefinitions, r.os)
+		selected, report.MissingParameters = dependencies.Artifacts, dependencies.Missing
+	}
+	for _, name := range unknown {
+		report.Artifacts = append(report.Artifacts, ArtifactReport{
+			Name: name, Status: StatusFailed,
+			Error: fmt.Sprintf("artifact definition %s not found%s", name, didYouMean(SuggestNames(name, artifactDefinitions))),
+		})
+	}
+	for _, artifactDefinition := range selected {
+		report.Artifacts = append(report.Artifacts, r.runArtifact(artifactDefinition))
+	}
+
+	report.End = time.Now().UTC()
+	return report
+}
+
+// runner holds the state of a run.
+type runner struct {
+	target        ExpansionTarget
+	collectSource collectFunc
+	cancelled     func() error
+	os            string
+	dedup         *deduplicator
+	journal       *Journal
+	fileFilter    *FileFilter
+	expand        ExpandOptions
+}
+
+func (r *runner) runArtifact(artifactDefinition ArtifactDefinition) ArtifactReport {
+	report := ArtifactReport{Name: artifactDefinition.Name, Status: StatusSkipped}
+	start := time.Now()
+	for i, source := range artifactDefinition.Sources {
+		if source.Type == SourceType.ArtifactGroup {
+			continue
+		}
+
+		sourceReport := SourceReport{Index: i, Type: source.Type, Status: StatusSkipped, Start: time.Now().UTC()}
+		if !IsOSArtifactDefinition(r.os, source.SupportedOs) {
+			sourceReport.Error = fmt.Sprintf("not supported on %s", r.os)
+			report.Sources = append(report.Sources, sourceReport)
+			continue
+		}
+		if r.cancelled != nil {
+			if err := r.cancelled(); err != nil {
+				sourceReport.Error = err.Error()
+				report.Sources = append(report.Sources, sourceReport)
+				continue
+			}
+		}
+
+		sourceStart := time.Now()
+		r.runSource(artifactDefinition, i, source, &sourceReport)
+		switch {
+		case sourceReport.Status == StatusFailed:
+			report.Status, report.Error = StatusFailed, sourceReport.Error
+		case sourceReport.Status != StatusSkipped && report.Status == StatusSkipped:
+			report.Status = StatusCollected
+		}
+		sourceReport.Duration = time.Since(sourceStart)
+		report.Sources = append(report.Sources, sourceReport)
+	}
+	report.Duration = time.Since(start)
+	return report
+}
+
+// runSource collects a single source. If the run uses expand options,
+// de-duplicates items or uses a journal, the source is expanded first and
+// only the remaining items are collected.
+func (r *runner) runSource(artifactDefinition ArtifactDefinition, index int, source Source, report *SourceReport) {
+	name := artifactDefinition.Name
+	var options *ExpandOptions
+	if expand := r.expand.ForDefinition(artifactDefinition); !expand.isZero() {
+		if err := expand.Validate(); err != nil {
+			report.Status, report.Error = StatusFailed, err.Error()
+			return
+		}
+		options = &expand
+	}
+
+	items, filter := []string(nil), false
+	if options != nil || r.dedup != nil || r.journal != nil || (r.fileFilter != nil && source.Type == SourceType.File) {
+		items, report.Excluded, filter = sourceItems(source, r.target, options)
+		// the collector must not expand the source again without the
+		// options, e.g. following symlinks the options do not allow
+		if !filter && hasItems(source.Type) {
+			report.Error = "no items"
+			if len(report.Excluded) > 0 {
+				report.Error = "all items excluded"
+			}
+			return
+		}
+	}
+	if filter {
+		if r.fileFilter != nil && source.Type == SourceType.File {
+			items, report.Filtered = r.fileFilter.filter(r.target.FS(), items)
+		}
+		if r.dedup != nil {
+			items, report.Duplicates = r.dedup.filter(name, index, source.Type, items)
+		}
+		if r.journal != nil {
+			items, report.Resumed = r.journal.filter(name, index, items)
+		}
+		if len(items) == 0 {
+			switch {
+			case len(report.Duplicates) > 0:
+				report.Status = StatusCollected
+			case len(report.Resumed) > 0:
+				report.Status = StatusResumed
+			default:
+				report.Error = "all items filtered"
+			}
+			return
+		}
+		source = withItems(source, items)
+	} else if r.journal != nil && r.journal.Done(name, index, "") {
+		report.Status = StatusResumed
+		return
+	}
+
+	result, err := collect(r.collectSource, name, index, source)
+	report.Result = result
+	if err != nil {
+		report.Status, report.Error = StatusFailed, err.Error()
+		return
+	}
+	report.Status = StatusCollected
+
+	if r.journal != nil {
+		if !filter {
+			items = []string{""}
+		}
+		if err := r.journal.Record(name, index, items); err != nil {
+			report.Status, report.Error = StatusFailed, err.Error()
+		}
+	}
+}
+
+// collect calls a collectFunc and converts panics into errors.
+func collect(collectSource collectFunc, name string, index int, source Source) (result Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("collection of %s failed: %v", name, r)
+		}
+	}()
+	return collectSource(name, index, source)
+}
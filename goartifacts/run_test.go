@@ -0,0 +1,210 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"testing/fstest"
+)
+
+type panicCollector struct {
+	TestCollector
+}
+
+func (r *panicCollector) Collect(name string, source Source) {
+	if name == "Panic" {
+		panic("broken collector")
+	}
+	r.TestCollector.Collect(name, source)
+}
+
+func getRunDefinitions() []ArtifactDefinition {
+	file := func(os ...string) Source {
+		return Source{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/foo.bin"}}, SupportedOs: os}
+	}
+	group := func(names ...string) Source {
+		return Source{Type: SourceType.ArtifactGroup, Attributes: Attributes{Names: names}}
+	}
+	return []ArtifactDefinition{
+		{Name: "Group", Sources: []Source{group("C", "Nested", "A")}},
+		{Name: "Nested", Sources: []Source{group("B", "A", "Other")}},
+		{Name: "A", Sources: []Source{file(), file("Other")}},
+		{Name: "B", Sources: []Source{file("Linux", "Windows", "Darwin")}},
+		{Name: "C", Sources: []Source{file()}},
+		{Name: "Other", Sources: []Source{file()}, SupportedOs: []string{"Other"}},
+		{Name: "Panic", Sources: []Source{file()}},
+	}
+}
+
+func TestRun(t *testing.T) {
+	collector := &panicCollector{TestCollector{fs: getInFS()}}
+	report := Run(getRunDefinitions(), []string{"Group", "Unknown", "Panic", "C"}, collector, RunOptions{OS: "Linux"})
+
+	var names []string
+	var statuses []Status
+	for _, artifact := range report.Artifacts {
+		names = append(names, artifact.Name)
+		statuses = append(statuses, artifact.Status)
+	}
+	if want := []string{"Unknown", "C", "B", "A", "Panic"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Run() artifacts = %v, want %v", names, want)
+	}
+	if want := []Status{StatusFailed, StatusCollected, StatusCollected, StatusCollected, StatusFailed}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("Run() statuses = %v, want %v", statuses, want)
+	}
+
+	a := report.Artifacts[3]
+	if len(a.Sources) != 2 || a.Sources[0].Status != StatusCollected || a.Sources[1].Status != StatusSkipped || a.Sources[1].Index != 1 {
+		t.Errorf("Run() sources = %#v", a.Sources)
+	}
+	if len(report.Failed()) != 2 {
+		t.Errorf("Failed() = %#v", report.Failed())
+	}
+	if len(collector.Collected) != 3 || len(collector.Collected["A"]) != 1 {
+		t.Errorf("Collect() calls = %#v", collector.Collected)
+	}
+	if report.End.Before(report.Start) {
+		t.Errorf("Run() end %s before start %s", report.End, report.Start)
+	}
+}
+
+func TestRunDeduplicate(t *testing.T) {
+	files := func(paths ...string) Source {
+		return Source{Type: SourceType.File, Attributes: Attributes{Paths: paths}}
+	}
+	definitions := []ArtifactDefinition{
+		{Name: "A", Sources: []Source{files("/foo.bin", "/dir/bar.bin")}},
+		{Name: "B", Sources: []Source{files("/dir/*.bin"), files("/foo.bin")}},
+		{Name: "C", Sources: []Source{files(`\dir\bar.bin`), {Type: SourceType.Directory, Attributes: Attributes{Paths: []string{"/dir/bar.bin"}}}}},
+	}
+	definitions[2].Sources[0].Attributes.Separator = `\`
+
+	collector := &TestCollector{fs: getInFS()}
+	report := Run(definitions, []string{"A", "B", "C"}, collector, RunOptions{OS: "Linux", Deduplicate: true})
+
+	var collected []string
+	for _, name := range []string{"A", "B", "C"} {
+		for _, source := range collector.Collected[name] {
+			for _, p := range source.Attributes.Paths {
+				collected = append(collected, name+" "+source.Type+" "+p)
+			}
+		}
+	}
+	want := []string{"A FILE foo.bin", "A FILE dir/bar.bin", "B FILE dir/baz.bin", "C DIRECTORY dir/bar.bin"}
+	if !reflect.DeepEqual(collected, want) {
+		t.Errorf("Run() collected = %v, want %v", collected, want)
+	}
+
+	b := report.Artifacts[1]
+	if b.Status != StatusCollected || !reflect.DeepEqual(b.Sources[0].Duplicates, []string{"dir/bar.bin"}) || b.Sources[1].Status != StatusCollected {
+		t.Errorf("Run() sources = %#v", b.Sources)
+	}
+	if len(collector.Collected["B"]) != 1 || len(collector.Collected["C"]) != 1 {
+		t.Errorf("Collect() calls = %#v", collector.Collected)
+	}
+
+	wantItems := []ItemReport{
+		{Type: SourceType.File, Item: "foo.bin", Sources: []ItemSource{{"A", 0}, {"B", 1}}},
+		{Type: SourceType.File, Item: "dir/bar.bin", Sources: []ItemSource{{"A", 0}, {"B", 0}, {"C", 0}}},
+		{Type: SourceType.File, Item: "dir/baz.bin", Sources: []ItemSource{{"B", 0}}},
+		{Type: SourceType.Directory, Item: "dir/bar.bin", Sources: []ItemSource{{"C", 1}}},
+	}
+	if !reflect.DeepEqual(report.Items, wantItems) {
+		t.Errorf("Run() items = %#v, want %#v", report.Items, wantItems)
+	}
+}
+
+func TestRunExpand(t *testing.T) {
+	files := func(paths ...string) Source {
+		return Source{Type: SourceType.File, Attributes: Attributes{Paths: paths}}
+	}
+	definitions := []ArtifactDefinition{
+		{Name: "A", Extensions: Extensions{Exclude: []string{"/dir/baz.bin"}}, Sources: []Source{files("/dir/*.bin")}},
+		{Name: "B", Sources: []Source{files("/foo.bin"), files("/dir/a/**")}},
+		{Name: "C", Extensions: Extensions{Exclude: []string{"re:("}}, Sources: []Source{files("/foo.bin")}},
+	}
+
+	collector := &TestCollector{fs: getInFS()}
+	report := Run(definitions, []string{"A", "B", "C"}, collector, RunOptions{OS: "Linux", Expand: ExpandOptions{Exclude: []string{"/foo.bin", "/dir/a/b"}}})
+
+	var collected []string
+	for _, name := range []string{"A", "B", "C"} {
+		for _, source := range collector.Collected[name] {
+			collected = append(collected, source.Attributes.Paths...)
+		}
+	}
+	want := []string{"dir/bar.bin", "dir/a/a", "dir/a/a/foo.bin"}
+	if !reflect.DeepEqual(collected, want) {
+		t.Errorf("Run() collected = %v, want %v", collected, want)
+	}
+
+	a, b, c := report.Artifacts[0], report.Artifacts[1], report.Artifacts[2]
+	if !reflect.DeepEqual(a.Sources[0].Excluded, []ExcludedPath{{"dir/baz.bin", "/dir/baz.bin"}}) {
+		t.Errorf("Run() excluded = %v", a.Sources[0].Excluded)
+	}
+	if b.Sources[0].Status != StatusSkipped || b.Sources[0].Error != "all items excluded" || b.Status != StatusCollected {
+		t.Errorf("Run() sources = %#v", b.Sources)
+	}
+	if !reflect.DeepEqual(b.Sources[1].Excluded, []ExcludedPath{{"dir/a/b", "/dir/a/b"}}) {
+		t.Errorf("Run() excluded = %v", b.Sources[1].Excluded)
+	}
+	if c.Status != StatusFailed {
+		t.Errorf("Run() status = %s, want failed", c.Status)
+	}
+
+	report = Run(definitions, []string{"A"}, collector, RunOptions{Expand: ExpandOptions{Exclude: []string{"re:("}}})
+	if report.Error == "" || len(report.Artifacts) != 0 {
+		t.Errorf("Run() = %#v, want error", report)
+	}
+}
+
+func TestRunExpanded(t *testing.T) {
+	names := []string{"dir/50%off%.txt", "dir/[a].txt", "dir/*.txt", "dir/{b}.txt"}
+	fsys := fstest.MapFS{}
+	for _, name := range names {
+		fsys[name] = &fstest.MapFile{Data: []byte(name)}
+	}
+	definitions := []ArtifactDefinition{
+		{Name: "A", Sources: []Source{{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/dir/*"}}}}},
+	}
+
+	for _, options := range []RunOptions{
+		{OS: "Linux", Deduplicate: true},
+		{OS: "Linux", FileFilter: &FileFilter{MaxSize: 1024}},
+		{OS: "Linux", Expand: ExpandOptions{Exclude: []string{"/other"}}},
+	} {
+		collector := &TestCollector{fs: fsys}
+		report := Run(definitions, []string{"A"}, collector, options)
+		if report.Artifacts[0].Status != StatusCollected || len(collector.Collected["A"]) != 1 {
+			t.Fatalf("Run() = %#v", report.Artifacts)
+		}
+		got := append([]string{}, collector.Collected["A"][0].Attributes.Paths...)
+		sort.Strings(got)
+		want := append([]string{}, names...)
+		sort.Strings(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Run() collected = %v, want %v", got, want)
+		}
+	}
+}
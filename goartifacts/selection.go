@@ -0,0 +1,213 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"sort"
+	"strings"
+)
+
+// Order defines the order of selected artifacts.
+type Order int
+
+const (
+	// SelectionOrder orders artifacts by their first reference. Names are
+	// visited in the given order and artifact groups are resolved depth
+	// first, so an artifact precedes the members of its groups.
+	SelectionOrder Order = iota
+	// TopologicalOrder orders artifacts so that every artifact group
+	// precedes its members. Artifacts without such a relation are ordered
+	// by name, so the order does not depend on the order of the names.
+	TopologicalOrder
+)
+
+// SelectOptions configure SelectArtifacts.
+type SelectOptions struct {
+	// OS is the operating system of the target. If empty runtime.GOOS is
+	// used.
+	OS    string
+	Order Order
+}
+
+// SelectedArtifact is an artifact selected by SelectArtifacts.
+type SelectedArtifact struct {
+	Definition ArtifactDefinition `json:"definition"`
+	// Provenance lists the artifact groups that pulled the artifact into
+	// the selection, starting with the selected name. It is empty if the
+	// artifact was selected directly.
+	Provenance []string `json:"provenance,omitempty"`
+}
+
+// UnknownNamesError lists names that are not defined.
+type UnknownNamesError struct {
+	Names []string
+	// Suggestions maps unknown names to similar defined names, see
+	// SuggestNames.
+	Suggestions map[string][]string
+}
+
+func newUnknownNamesError(names []string, artifactDefinitions []ArtifactDefinition) *UnknownNamesError {
+	err := &UnknownNamesError{Names: names, Suggestions: map[string][]string{}}
+	for _, name := range names {
+		if suggestions := SuggestNames(name, artifactDefinitions); len(suggestions) > 0 {
+			err.Suggestions[name] = suggestions
+		}
+	}
+	return err
+}
+
+func (e *UnknownNamesError) Error() string {
+	var names []string
+	for _, name := range e.Names {
+		if suggestions := e.Suggestions[name]; len(suggestions) > 0 {
+			name += " (did you mean " + strings.Join(suggestions, " or ") + "?)"
+		}
+		names = append(names, name)
+	}
+	return "unknown artifact definitions: " + strings.Join(names, ", ")
+}
+
+// SelectArtifacts resolves the names and all artifact groups they contain
+// into the artifacts to collect. Every artifact is selected once and only
+// artifacts and group members that support the operating system are
+// included. Artifacts that only consist of artifact groups are not part of
+// the selection. The definitions are returned unmodified, sources are not
+// filtered by operating system.
+//
+// If names or group members are not defined, the selection of all defined
+// artifacts is returned with an *UnknownNamesError.
+func SelectArtifacts(names []string, artifactDefinitions []ArtifactDefinition, options SelectOptions) ([]SelectedArtifact, error) {
+	s := &selector{
+		definitions: map[string]ArtifactDefinition{},
+		os:          RunOptions{OS: options.OS}.os(),
+		visited:     map[string]bool{},
+	}
+	for _, artifactDefinition := range artifactDefinitions {
+		s.definitions[artifactDefinition.Name] = artifactDefinition
+	}
+	for _, name := range names {
+		s.visit(name, nil)
+	}
+
+	selected := s.selected
+	if options.Order == TopologicalOrder {
+		selected = s.topological()
+	}
+	if len(s.unknown) > 0 {
+		return selected, newUnknownNamesError(s.unknown, artifactDefinitions)
+	}
+	return selected, nil
+}
+
+// selector implements SelectArtifacts.
+type selector struct {
+	definitions map[string]ArtifactDefinition
+	os          string
+	visited     map[string]bool
+	order       []string
+	selected    []SelectedArtifact
+	unknown     []string
+}
+
+func (s *selector) visit(name string, provenance []string) {
+	if s.visited[name] {
+		return
+	}
+	s.visited[name] = true
+
+	artifactDefinition, ok := s.definitions[name]
+	if !ok {
+		s.unknown = append(s.unknown, name)
+		return
+	}
+	if !IsOSArtifactDefinition(s.os, artifactDefinition.SupportedOs) {
+		return
+	}
+	s.order = append(s.order, name)
+
+	for _, source := range artifactDefinition.Sources {
+		if source.Type != SourceType.ArtifactGroup {
+			s.selected = append(s.selected, SelectedArtifact{Definition: artifactDefinition, Provenance: provenance})
+			break
+		}
+	}
+	for _, source := range artifactDefinition.Sources {
+		if source.Type == SourceType.ArtifactGroup && IsOSArtifactDefinition(s.os, source.SupportedOs) {
+			for _, member := range source.Attributes.Names {
+				s.visit(member, appendString(provenance, name))
+			}
+		}
+	}
+}
+
+// topological sorts the selection with GroupGraph.TopologicalOrder. The
+// graph is built from the definitions ordered by name, so artifacts without
+// a group relation are ordered by name. Artifacts in cycles are appended in
+// selection order.
+func (s *selector) topological() []SelectedArtifact {
+	names := appendString(nil, s.order...)
+	sort.Strings(names)
+	definitions := make([]ArtifactDefinition, len(names))
+	for i, name := range names {
+		definitions[i] = s.definitions[name]
+	}
+	// on cycles the artifacts that could be sorted are returned
+	sorted, _ := NewGroupGraph(definitions, s.os).TopologicalOrder()
+
+	position := map[string]int{}
+	for i, name := range sorted {
+		position[name] = i
+	}
+	for _, name := range s.order {
+		if _, ok := position[name]; !ok {
+			position[name] = len(position)
+		}
+	}
+	selected := make([]SelectedArtifact, len(s.selected))
+	copy(selected, s.selected)
+	sort.Sort(byPosition{selected, position})
+	return selected
+}
+
+type byPosition struct {
+	artifacts []SelectedArtifact
+	position  map[string]int
+}
+
+func (a byPosition) Len() int      { return len(a.artifacts) }
+func (a byPosition) Swap(i, j int) { a.artifacts[i], a.artifacts[j] = a.artifacts[j], a.artifacts[i] }
+func (a byPosition) Less(i, j int) bool {
+	return a.position[a.artifacts[i].Definition.Name] < a.position[a.artifacts[j].Definition.Name]
+}
+
+// selectArtifacts returns the definitions selected by SelectArtifacts in
+// selection order and the unknown names.
+func selectArtifacts(names []string, artifactDefinitions []ArtifactDefinition, operatingSystem string) (selected []ArtifactDefinition, unknown []string) {
+	artifacts, err := SelectArtifacts(names, artifactDefinitions, SelectOptions{OS: operatingSystem})
+	if unknownErr, ok := err.(*UnknownNamesError); ok {
+		unknown = unknownErr.Names
+	}
+	for _, artifact := range artifacts {
+		selected = append(selected, artifact.Definition)
+	}
+	return selected, unknown
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectArtifacts(t *testing.T) {
+	definitions := getRunDefinitions()
+	definitions = append(definitions, ArtifactDefinition{Name: "Broken", Sources: []Source{{Type: SourceType.ArtifactGroup, Attributes: Attributes{Names: []string{"Missing", "C"}}}}})
+
+	tests := []struct {
+		name           string
+		names          []string
+		order          Order
+		want           []string
+		wantProvenance [][]string
+		wantUnknown    []string
+	}{
+		{"selection", []string{"Group", "Panic"}, SelectionOrder, []string{"C", "B", "A", "Panic"}, [][]string{{"Group"}, {"Group", "Nested"}, {"Group", "Nested"}, nil}, nil},
+		{"selection direct", []string{"A", "Group"}, SelectionOrder, []string{"A", "C", "B"}, [][]string{nil, {"Group"}, {"Group", "Nested"}}, nil},
+		{"topological", []string{"Panic", "Group"}, TopologicalOrder, []string{"C", "A", "B", "Panic"}, [][]string{{"Group"}, {"Group", "Nested"}, {"Group", "Nested"}, nil}, nil},
+		{"topological order independent", []string{"A", "Group", "Panic"}, TopologicalOrder, []string{"C", "A", "B", "Panic"}, [][]string{{"Group"}, nil, {"Group", "Nested"}, nil}, nil},
+		{"unknown", []string{"Unknown", "Broken"}, SelectionOrder, []string{"C"}, [][]string{{"Broken"}}, []string{"Unknown", "Missing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := SelectArtifacts(tt.names, definitions, SelectOptions{OS: "Linux", Order: tt.order})
+			var names []string
+			var provenance [][]string
+			for _, artifact := range selected {
+				names = append(names, artifact.Definition.Name)
+				provenance = append(provenance, artifact.Provenance)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("SelectArtifacts() = %v, want %v", names, tt.want)
+			}
+			if !reflect.DeepEqual(provenance, tt.wantProvenance) {
+				t.Errorf("SelectArtifacts() provenance = %v, want %v", provenance, tt.wantProvenance)
+			}
+			if tt.wantUnknown == nil {
+				if err != nil {
+					t.Errorf("SelectArtifacts() error = %v", err)
+				}
+				return
+			}
+			unknownErr, ok := err.(*UnknownNamesError)
+			if !ok || !reflect.DeepEqual(unknownErr.Names, tt.wantUnknown) {
+				t.Errorf("SelectArtifacts() error = %v, want %v", err, tt.wantUnknown)
+			}
+		})
+	}
+}
+
+func TestSelectArtifactsCycle(t *testing.T) {
+	group := func(names ...string) Source {
+		return Source{Type: SourceType.ArtifactGroup, Attributes: Attributes{Names: names}}
+	}
+	file := Source{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/foo.bin"}}}
+	definitions := []ArtifactDefinition{
+		{Name: "X", Sources: []Source{file, group("Y")}},
+		{Name: "Y", Sources: []Source{file, group("X", "Z")}},
+		{Name: "Z", Sources: []Source{file}},
+	}
+	selected, err := SelectArtifacts([]string{"Z", "X"}, definitions, SelectOptions{OS: "Linux", Order: TopologicalOrder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, artifact := range selected {
+		names = append(names, artifact.Definition.Name)
+	}
+	if want := []string{"Z", "X", "Y"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("SelectArtifacts() = %v, want %v", names, want)
+	}
+}
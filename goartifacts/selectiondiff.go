@@ -0,0 +1,242 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SelectionDiff describes how the selection of a profile changes between two
+// versions of the artifact definitions.
+type SelectionDiff struct {
+	OS []OSSelectionDiff `json:"os"`
+}
+
+// Empty returns whether the selection is unchanged on all operating systems.
+func (d *SelectionDiff) Empty() bool {
+	for _, osDiff := range d.OS {
+		if !osDiff.Empty() {
+			return false
+		}
+	}
+	return true
+}
+
+// OSSelectionDiff describes the selection changes for one operating system.
+type OSSelectionDiff struct {
+	OS string `json:"os"`
+	// Entered lists the artifacts that are only selected with the new
+	// definitions, Left the artifacts that are only selected with the old
+	// definitions.
+	Entered []string `json:"entered,omitempty"`
+	Left    []string `json:"left,omitempty"`
+	// Changed lists the changed sources of artifacts that are selected with
+	// both versions.
+	Changed []SourceChange `json:"changed,omitempty"`
+	// AddedItems and RemovedItems list the raw paths, keys, values, commands
+	// and WMI queries of the whole selection.
+	AddedItems   []RawItem `json:"added_items,omitempty"`
+	RemovedItems []RawItem `json:"removed_items,omitempty"`
+	// Unknown lists names of the profile that are not defined in the new
+	// definitions.
+	Unknown []string `json:"unknown,omitempty"`
+}
+
+// Empty returns whether the selection is unchanged.
+func (d OSSelectionDiff) Empty() bool {
+	return len(d.Entered) == 0 && len(d.Left) == 0 && len(d.Changed) == 0 &&
+		len(d.AddedItems) == 0 && len(d.RemovedItems) == 0 && len(d.Unknown) == 0
+}
+
+// SourceChange lists the sources of an artifact that were added or removed.
+// Modified sources are reported as removed and added.
+type SourceChange struct {
+	Artifact string   `json:"artifact"`
+	Added    []Source `json:"added,omitempty"`
+	Removed  []Source `json:"removed,omitempty"`
+}
+
+// RawItem is an unexpanded item of a source. Registry values are encoded as
+// key and value name separated by a backslash, commands as the command
+// followed by the arguments.
+type RawItem struct {
+	Artifact string `json:"artifact"`
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+}
+
+// DiffSelection compares the selection of a profile with the old and the new
+// artifact definitions. A selection of names or queries can be compared with
+// a profile that only sets Artifacts or Queries. The selection is compared
+// for the given operating systems, for the operating systems of the profile
+// or for Windows, Linux and Darwin if neither is set. Only sources that
+// support the operating system are compared.
+func DiffSelection(oldDefinitions, newDefinitions []ArtifactDefinition, profile Profile, operatingSystems ...string) (*SelectionDiff, error) {
+	if len(operatingSystems) == 0 {
+		operatingSystems = profile.OS
+	}
+	if len(operatingSystems) == 0 {
+		operatingSystems = []string{"Windows", "Linux", "Darwin"}
+	}
+
+	diff := &SelectionDiff{}
+	for _, operatingSystem := range operatingSystems {
+		oldSelection, err := profile.Select(oldDefinitions, operatingSystem)
+		if _, ok := err.(*UnknownNamesError); err != nil && !ok {
+			return nil, err
+		}
+		newSelection, err := profile.Select(newDefinitions, operatingSystem)
+		var unknown []string
+		if unknownErr, ok := err.(*UnknownNamesError); ok {
+			unknown = unknownErr.Names
+		} else if err != nil {
+			return nil, err
+		}
+
+		osDiff := diffSelection(oldSelection, newSelection, operatingSystem)
+		osDiff.Unknown = unknown
+		diff.OS = append(diff.OS, osDiff)
+	}
+	return diff, nil
+}
+
+func diffSelection(oldSelection, newSelection []ArtifactDefinition, operatingSystem string) OSSelectionDiff {
+	diff := OSSelectionDiff{OS: operatingSystem}
+
+	oldArtifacts := map[string]ArtifactDefinition{}
+	for _, artifactDefinition := range oldSelection {
+		oldArtifacts[artifactDefinition.Name] = artifactDefinition
+	}
+	newArtifacts := map[string]ArtifactDefinition{}
+	for _, artifactDefinition := range newSelection {
+		newArtifacts[artifactDefinition.Name] = artifactDefinition
+	}
+
+	for _, artifactDefinition := range oldSelection {
+		if _, ok := newArtifacts[artifactDefinition.Name]; !ok {
+			diff.Left = append(diff.Left, artifactDefinition.Name)
+		}
+	}
+	for _, artifactDefinition := range newSelection {
+		oldDefinition, ok := oldArtifacts[artifactDefinition.Name]
+		if !ok {
+			diff.Entered = append(diff.Entered, artifactDefinition.Name)
+			continue
+		}
+		added := subtractSources(osSources(artifactDefinition, operatingSystem), osSources(oldDefinition, operatingSystem))
+		removed := subtractSources(osSources(oldDefinition, operatingSystem), osSources(artifactDefinition, operatingSystem))
+		if len(added) > 0 || len(removed) > 0 {
+			diff.Changed = append(diff.Changed, SourceChange{Artifact: artifactDefinition.Name, Added: added, Removed: removed})
+		}
+	}
+
+	oldItems := rawItems(oldSelection, operatingSystem)
+	newItems := rawItems(newSelection, operatingSystem)
+	diff.AddedItems = subtractItems(newItems, oldItems)
+	diff.RemovedItems = subtractItems(oldItems, newItems)
+	return diff
+}
+
+// osSources returns the sources of the artifact definition that support the
+// operating system.
+func osSources(artifactDefinition ArtifactDefinition, operatingSystem string) []Source {
+	var sources []Source
+	for _, source := range artifactDefinition.Sources {
+		if IsOSArtifactDefinition(operatingSystem, source.SupportedOs) {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// subtractSources returns the sources of a that are not in b. Equal sources
+// are matched one by one, so duplicates are retained.
+func subtractSources(a, b []Source) []Source {
+	matched := make([]bool, len(b))
+	var remaining []Source
+	for _, source := range a {
+		found := false
+		for i, other := range b {
+			if !matched[i] && reflect.DeepEqual(source, other) {
+				matched[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			remaining = append(remaining, source)
+		}
+	}
+	return remaining
+}
+
+func rawItems(artifactDefinitions []ArtifactDefinition, operatingSystem string) []RawItem {
+	var items []RawItem
+	seen := map[RawItem]bool{}
+	for _, artifactDefinition := range artifactDefinitions {
+		for _, source := range osSources(artifactDefinition, operatingSystem) {
+			for _, value := range sourceRawValues(source) {
+				item := RawItem{Artifact: artifactDefinition.Name, Type: source.Type, Value: value}
+				if !seen[item] {
+					seen[item] = true
+					items = append(items, item)
+				}
+			}
+		}
+	}
+	return items
+}
+
+func sourceRawValues(source Source) []string {
+	switch source.Type {
+	case SourceType.File, SourceType.Directory, SourceType.Path:
+		return source.Attributes.Paths
+	case SourceType.RegistryKey:
+		return source.Attributes.Keys
+	case SourceType.RegistryValue:
+		var values []string
+		for _, pair := range source.Attributes.KeyValuePairs {
+			values = append(values, pair.Key+`\`+pair.Value)
+		}
+		return values
+	case SourceType.Command:
+		return []string{strings.Join(append([]string{source.Attributes.Cmd}, source.Attributes.Args...), " ")}
+	case SourceType.Wmi:
+		return []string{source.Attributes.Query}
+	}
+	return nil
+}
+
+func subtractItems(a, b []RawItem) []RawItem {
+	contained := map[RawItem]bool{}
+	for _, item := range b {
+		contained[item] = true
+	}
+	var remaining []RawItem
+	for _, item := range a {
+		if !contained[item] {
+			remaining = append(remaining, item)
+		}
+	}
+	return remaining
+}
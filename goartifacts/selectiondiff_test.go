@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSelection(t *testing.T) {
+	group := func(names ...string) Source {
+		return Source{Type: SourceType.ArtifactGroup, Attributes: Attributes{Names: names}}
+	}
+	oldDefinitions := []ArtifactDefinition{
+		{Name: "Triage", Sources: []Source{group("Hosts", "Run", "Hostname")}},
+		{Name: "Hosts", Sources: []Source{{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/etc/hosts"}}}}},
+		{Name: "Run", SupportedOs: []string{"Windows"}, Sources: []Source{
+			{Type: SourceType.RegistryKey, Attributes: Attributes{Keys: []string{`HKEY_LOCAL_MACHINE\Run\*`}}},
+		}},
+		{Name: "Hostname", Sources: []Source{{Type: SourceType.Command, Attributes: Attributes{Cmd: "hostname"}}}},
+	}
+	newDefinitions := []ArtifactDefinition{
+		{Name: "Triage", Sources: []Source{group("Hosts", "Run", "Processes", "Missing")}},
+		{Name: "Hosts", Sources: []Source{
+			{Type: SourceType.File, Attributes: Attributes{Paths: []string{"/etc/hosts", "/private/etc/hosts"}}},
+			{Type: SourceType.File, Attributes: Attributes{Paths: []string{`%%environ_systemroot%%\System32\drivers\etc\hosts`}}, SupportedOs: []string{"Windows"}},
+		}},
+		{Name: "Run", SupportedOs: []string{"Windows"}, Sources: []Source{
+			{Type: SourceType.RegistryKey, Attributes: Attributes{Keys: []string{`HKEY_LOCAL_MACHINE\Run\*`}}},
+		}},
+		{Name: "Processes", Sources: []Source{{Type: SourceType.Command, Attributes: Attributes{Cmd: "ps", Args: []string{"aux"}}}}},
+	}
+
+	diff, err := DiffSelection(oldDefinitions, newDefinitions, Profile{Artifacts: []string{"Triage"}}, "Linux", "Windows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &SelectionDiff{OS: []OSSelectionDiff{
+		{
+			OS:      "Linux",
+			Entered: []string{"Processes"},
+			Left:    []string{"Hostname"},
+			Changed: []SourceChange{{
+				Artifact: "Hosts",
+				Added:    []Source{newDefinitions[1].Sources[0]},
+				Removed:  []Source{oldDefinitions[1].Sources[0]},
+			}},
+			AddedItems: []RawItem{
+				{"Hosts", SourceType.File, "/private/etc/hosts"},
+				{"Processes", SourceType.Command, "ps aux"},
+			},
+			RemovedItems: []RawItem{{"Hostname", SourceType.Command, "hostname"}},
+			Unknown:      []string{"Missing"},
+		},
+		{
+			OS:      "Windows",
+			Entered: []string{"Processes"},
+			Left:    []string{"Hostname"},
+			Changed: []SourceChange{{
+				Artifact: "Hosts",
+				Added:    newDefinitions[1].Sources,
+				Removed:  []Source{oldDefinitions[1].Sources[0]},
+			}},
+			AddedItems: []RawItem{
+				{"Hosts", SourceType.File, "/private/etc/hosts"},
+				{"Hosts", SourceType.File, `%%environ_systemroot%%\System32\drivers\etc\hosts`},
+				{"Processes", SourceType.Command, "ps aux"},
+			},
+			RemovedItems: []RawItem{{"Hostname", SourceType.Command, "hostname"}},
+			Unknown:      []string{"Missing"},
+		},
+	}}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("DiffSelection() = %+v, want %+v", diff, want)
+	}
+	if diff.Empty() {
+		t.Error("Empty() = true")
+	}
+
+	same, err := DiffSelection(oldDefinitions, oldDefinitions, Profile{Queries: []string{"type:COMMAND"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(same.OS) != 3 || !same.Empty() {
+		t.Errorf("DiffSelection() = %+v, want empty diff", same)
+	}
+
+	if _, err := DiffSelection(oldDefinitions, newDefinitions, Profile{Queries: []string{"name:("}}); err == nil {
+		t.Error("DiffSelection() expected query error")
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggestNames(t *testing.T) {
+	var definitions []ArtifactDefinition
+	for _, name := range []string{"WindowsEventLogs", "WindowsEventLogsEvtx", "WindowsRunKeys", "LinuxAuthLogs", "BrowserHistory", "Ls"} {
+		definitions = append(definitions, ArtifactDefinition{Name: name})
+	}
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"WindowsEventLog", []string{"WindowsEventLogs", "WindowsEventLogsEvtx"}},
+		{"windowseventlogs", []string{"WindowsEventLogs", "WindowsEventLogsEvtx"}},
+		{"WindowsRunKey", []string{"WindowsRunKeys"}},
+		{"BrowserHistroy", []string{"BrowserHistory"}},
+		{"LinuxAuth", []string{"LinuxAuthLogs"}},
+		{"L", []string{"Ls"}},
+		{"MacOSUnifiedLogs", nil},
+		{"WindowsEventLogs", []string{"WindowsEventLogsEvtx"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SuggestNames(tt.name, definitions); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SuggestNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknownNamesErrorSuggestions(t *testing.T) {
+	definitions := []ArtifactDefinition{{Name: "WindowsEventLogs", Sources: []Source{{Type: SourceType.File}}}}
+	_, err := SelectArtifacts([]string{"WindowsEventLog", "Unrelated"}, definitions, SelectOptions{OS: "Windows"})
+	want := "unknown artifact definitions: WindowsEventLog (did you mean WindowsEventLogs?), Unrelated"
+	if err == nil || err.Error() != want {
+		t.Errorf("SelectArtifacts() error = %v, want %s", err, want)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// maxLinks is the maximal number of links followed for a single path, like
+// MAXSYMLINKS on Linux.
+const maxLinks = 40
+
+// SymlinkPolicy defines how symbolic links and junctions are handled while
+// expanding paths.
+type SymlinkPolicy int
+
+// Symlink policies.
+const (
+	// FollowSymlinks follows all links. Links that point to one of their own
+	// parents are not followed to avoid loops.
+	FollowSymlinks SymlinkPolicy = iota
+	// NoFollowSymlinks never traverses links. Links can still be matched
+	// themselves, but are not expanded any further.
+	NoFollowSymlinks
+	// FollowSymlinksWithinRoot follows only links with a relative target
+	// that stays within the file system. Absolute targets are never
+	// followed, as they might resolve outside of the evidence.
+	FollowSymlinksWithinRoot
+)
+
+// ReadLinkFS is implemented by file systems that support symbolic links. It
+// matches fs.ReadLinkFS of newer Go versions.
+type ReadLinkFS interface {
+	fs.FS
+	ReadLink(name string) (string, error)
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// resolvedPath describes how a path in the file system is reached.
+type resolvedPath struct {
+	real    string   // the path without any links
+	chain   []string // the real directories that were passed
+	links   []string // all links that were followed
+	stopped fs.FileInfo
+	blocked bool
+}
+
+// links returns the links that were followed to reach name.
+func (e *expansionFS) followedLinks(name string) []string {
+	if !e.links {
+		return nil
+	}
+	return e.resolve(name, 0).links
+}
+
+// lstat returns the fs.FileInfo of name without following a link in the
+// last element. If the file system does not implement ReadLinkFS, the parent
+// directory is listed instead.
+func (e *expansionFS) lstat(name string) fs.FileInfo {
+	if linkFS, ok := e.fsys.(ReadLinkFS); ok {
+		info, err := linkFS.Lstat(name)
+		if err != nil {
+			return nil
+		}
+		return info
+	}
+
+	entry, ok := e.entries[name]
+	if !ok {
+		parent := path.Dir(name)
+		if e.listed[parent] {
+			return nil
+		}
+		if _, err := e.readDir(parent); err != nil {
+			return nil
+		}
+		if entry, ok = e.entries[name]; !ok {
+			return nil
+		}
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// readLink returns the target of a link as path in the file system. Absolute
+// targets or targets outside of the file system are reported as outside.
+func (e *expansionFS) readLink(name, realParent string) (target string, outside, ok bool) {
+	linkFS, isLinkFS := e.fsys.(ReadLinkFS)
+	if !isLinkFS {
+		return "", false, false
+	}
+	target, err := linkFS.ReadLink(name)
+	if err != nil {
+		return "", false, false
+	}
+
+	target = strings.Replace(target, `\`, "/", -1)
+	target = strings.TrimPrefix(target, "//?/")
+	switch {
+	case strings.HasPrefix(target, "/"):
+		return path.Clean(target[1:]), true, true
+	case len(target) > 1 && target[1] == ':' && isLetter(target[0]):
+		return path.Clean(target[:1] + target[2:]), true, true
+	}
+
+	target = path.Join(realParent, target)
+	if target == ".." || strings.HasPrefix(target, "../") {
+		return "", true, true
+	}
+	return target, false, true
+}
+
+func isLink(info fs.FileInfo) bool {
+	return info != nil && info.Mode()&(fs.ModeSymlink|fs.ModeIrregular) != 0
+}
+
+// resolve walks name element by element and decides for every link if it is
+// followed.
+func (e *expansionFS) resolve(name string, depth int) *resolvedPath { // nolint:gocyclo
+	if r, ok := e.resolved[name]; ok {
+		return r
+	}
+	if name == "." || name == "" || depth > maxLinks {
+		r := &resolvedPath{real: ".", chain: []string{"."}, blocked: depth > maxLinks}
+		e.resolved[name] = r
+		return r
+	}
+
+	parent := e.resolve(path.Dir(name), depth)
+	if parent.blocked || parent.stopped != nil {
+		r := &resolvedPath{blocked: true}
+		e.resolved[name] = r
+		return r
+	}
+
+	real := path.Join(parent.real, path.Base(name))
+	r := &resolvedPath{real: real, chain: parent.chain, links: parent.links}
+
+	info := e.lstat(name)
+	if !isLink(info) {
+		r.chain = appendString(parent.chain, real)
+		e.resolved[name] = r
+		return r
+	}
+
+	follow := false
+	target, outside, ok := e.readLink(name, parent.real)
+	switch e.options.Symlinks {
+	case FollowSymlinks:
+		follow = true
+	case FollowSymlinksWithinRoot:
+		follow = ok && !outside
+	}
+
+	if follow && ok && target != "" {
+		// links inside of the target need to be checked as well
+		targetPath := e.resolve(target, depth+1)
+		switch {
+		case targetPath.blocked || targetPath.stopped != nil:
+			follow = false
+		case isLoop(targetPath.real, parent.chain):
+			follow = false
+		default:
+			r.real = targetPath.real
+			r.links = appendString(appendString(parent.links, targetPath.links...), name)
+			r.chain = appendString(parent.chain, targetPath.chain[1:]...)
+		}
+	} else if follow {
+		// the target is unknown, so loops can only be detected by counting
+		if len(parent.links) >= maxLinks {
+			follow = false
+		} else {
+			r.links = appendString(parent.links, name)
+			r.chain = appendString(parent.chain, real)
+		}
+	}
+
+	if !follow {
+		r.stopped = info
+	}
+	e.resolved[name] = r
+	return r
+}
+
+// isLoop checks if target is one of the directories in chain or a parent of
+// those.
+func isLoop(target string, chain []string) bool {
+	for _, dir := range chain {
+		if target == "." || target == dir || strings.HasPrefix(dir, target+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// appendString appends to a copy of s, so slices can be shared safely.
+func appendString(s []string, elems ...string) []string {
+	c := make([]string, 0, len(s)+len(elems))
+	c = append(c, s...)
+	return append(c, elems...)
+}
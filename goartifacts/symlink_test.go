@@ -0,0 +1,147 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+//go:build !windows
+// +build !windows
+
+package goartifacts
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type linkDirFS struct {
+	fs.FS
+	dir string
+}
+
+func (l linkDirFS) ReadLink(name string) (string, error) {
+	return os.Readlink(filepath.Join(l.dir, filepath.FromSlash(name)))
+}
+
+func (l linkDirFS) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(filepath.Join(l.dir, filepath.FromSlash(name)))
+}
+
+func getLinkFS(t *testing.T) string {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "private", "var", "log"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "private", "var", "log", "system.log"), []byte("test"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"var":                   "private/var",
+		"escape":                "/etc",
+		"private/up":            "..",
+		"private/out":           "../../outside",
+		"private/var/log/loop":  "..",
+		"private/var/log/again": "../../../var",
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, filepath.FromSlash(link))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestExpandSourceWithOptions_Symlinks(t *testing.T) {
+	dir := getLinkFS(t)
+
+	tests := []struct {
+		name   string
+		fsys   fs.FS
+		policy SymlinkPolicy
+		path   string
+		want   []ExpandedPath
+	}{
+		{"follow", linkDirFS{os.DirFS(dir), dir}, FollowSymlinks, "/var/log/*.log", []ExpandedPath{{Path: "var/log/system.log", Links: []string{"var"}}}},
+		{"follow loop", linkDirFS{os.DirFS(dir), dir}, FollowSymlinks, "/private/var/log/loop/log/*.log", nil},
+		{"follow loop via other link", linkDirFS{os.DirFS(dir), dir}, FollowSymlinks, "/private/var/log/again/log/*.log", nil},
+		{"no follow", linkDirFS{os.DirFS(dir), dir}, NoFollowSymlinks, "/var/log/*.log", nil},
+		{"no follow link itself", linkDirFS{os.DirFS(dir), dir}, NoFollowSymlinks, "/var", []ExpandedPath{{Path: "var"}}},
+		{"no follow without ReadLink", struct{ fs.FS }{os.DirFS(dir)}, NoFollowSymlinks, "/var/log/*.log", nil},
+		{"within root", linkDirFS{os.DirFS(dir), dir}, FollowSymlinksWithinRoot, "/var/log/*.log", []ExpandedPath{{Path: "var/log/system.log", Links: []string{"var"}}}},
+		{"within root absolute", linkDirFS{os.DirFS(dir), dir}, FollowSymlinksWithinRoot, "/escape/*", nil},
+		{"within root escaping", linkDirFS{os.DirFS(dir), dir}, FollowSymlinksWithinRoot, "/private/out/*", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := &TestCollector{fs: tt.fsys}
+			source := Source{Type: SourceType.File, Attributes: Attributes{Paths: []string{tt.path}}}
+			got := ExpandSourceWithOptions(source, collector, ExpandOptions{Symlinks: tt.policy})
+			if !reflect.DeepEqual(got.Paths, tt.want) {
+				t.Errorf("ExpandSourceWithOptions() = %#v, want %#v", got.Paths, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandSourceWithOptions_SymlinkLoop(t *testing.T) {
+	dir := getLinkFS(t)
+	collector := &TestCollector{fs: linkDirFS{os.DirFS(dir), dir}}
+	source := Source{Type: SourceType.Path, Attributes: Attributes{Paths: []string{"/private/**10"}}}
+
+	got := ExpandSourceWithOptions(source, collector, ExpandOptions{Symlinks: FollowSymlinks}).Source.Attributes.Paths
+	sort.Strings(got)
+	want := []string{"private/up", "private/var", "private/var/log", "private/var/log/again", "private/var/log/loop", "private/var/log/system.log"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandSourceWithOptions() = %v, want %v", got, want)
+	}
+}
+
+func TestRunSymlinks(t *testing.T) {
+	dir := getLinkFS(t)
+	files := func(paths ...string) Source {
+		return Source{Type: SourceType.File, Attributes: Attributes{Paths: paths}}
+	}
+	definitions := []ArtifactDefinition{
+		{Name: "Escape", Sources: []Source{files("/escape/passwd")}},
+		{Name: "Logs", Sources: []Source{files("/var/log/*.log")}},
+	}
+
+	collector := &TestCollector{fs: linkDirFS{os.DirFS(dir), dir}}
+	report := Run(definitions, []string{"Escape", "Logs"}, collector, RunOptions{OS: "Linux", Expand: ExpandOptions{Symlinks: NoFollowSymlinks}})
+	if len(collector.Collected) != 0 {
+		t.Errorf("Collect() calls = %#v", collector.Collected)
+	}
+	for _, artifact := range report.Artifacts {
+		if artifact.Status != StatusSkipped || artifact.Sources[0].Error != "no items" {
+			t.Errorf("Run() artifact = %#v", artifact)
+		}
+	}
+
+	collector = &TestCollector{fs: linkDirFS{os.DirFS(dir), dir}}
+	report = Run(definitions, []string{"Escape", "Logs"}, collector, RunOptions{OS: "Linux", Expand: ExpandOptions{Symlinks: FollowSymlinksWithinRoot}})
+	if report.Artifacts[0].Status != StatusSkipped || report.Artifacts[1].Status != StatusCollected {
+		t.Errorf("Run() artifacts = %#v", report.Artifacts)
+	}
+	if len(collector.Collected) != 1 || len(collector.Collected["Logs"]) != 1 {
+		t.Errorf("Collect() calls = %#v", collector.Collected)
+	}
+}
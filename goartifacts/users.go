@@ -0,0 +1,340 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// User is a user account of a system.
+type User struct {
+	Username string `json:"username,omitempty"`
+	UID      string `json:"uid,omitempty"`
+	GID      string `json:"gid,omitempty"`
+	SID      string `json:"sid,omitempty"`
+	FullName string `json:"full_name,omitempty"`
+	Shell    string `json:"shell,omitempty"`
+	Homedir  string `json:"homedir,omitempty"`
+	// Folders contains user specific folders like appdata by their
+	// knowledge base name.
+	Folders map[string]string `json:"folders,omitempty"`
+}
+
+// userFolders are the user specific folders known on Windows.
+var userFolders = []struct {
+	name     string
+	key      string
+	value    string
+	variable string
+	fallback string
+}{
+	{"appdata", shellFoldersKey, "AppData", "APPDATA", `%USERPROFILE%\AppData\Roaming`},
+	{"localappdata", shellFoldersKey, "Local AppData", "LOCALAPPDATA", `%USERPROFILE%\AppData\Local`},
+	{"localappdata_low", "", "", "", `%USERPROFILE%\AppData\LocalLow`},
+	{"desktop", shellFoldersKey, "Desktop", "", `%USERPROFILE%\Desktop`},
+	{"personal", shellFoldersKey, "Personal", "", `%USERPROFILE%\Documents`},
+	{"cookies", shellFoldersKey, "Cookies", "", `%LOCALAPPDATA%\Microsoft\Windows\INetCookies`},
+	{"recent", shellFoldersKey, "Recent", "", `%APPDATA%\Microsoft\Windows\Recent`},
+	{"startup", shellFoldersKey, "Startup", "", `%APPDATA%\Microsoft\Windows\Start Menu\Programs\Startup`},
+	{"internet_cache", shellFoldersKey, "Cache", "", `%LOCALAPPDATA%\Microsoft\Windows\INetCache`},
+	{"temp", "Environment", "TEMP", "TEMP", `%USERPROFILE%\AppData\Local\Temp`},
+}
+
+const (
+	profileListKey  = "HKEY_LOCAL_MACHINE/SOFTWARE/Microsoft/Windows NT/CurrentVersion/ProfileList"
+	shellFoldersKey = "Software/Microsoft/Windows/CurrentVersion/Explorer/User Shell Folders"
+	dslocalUsers    = "private/var/db/dslocal/nodes/Default/users"
+)
+
+// UserResolver resolves users.* parameters like users.homedir from a list
+// of users.
+type UserResolver struct {
+	Users []User
+}
+
+// NewUserResolver creates a UserResolver for the users of the collector's
+// file system and registry. The operating system is one of Windows, Darwin,
+// Linux or ESXi.
+func NewUserResolver(collector ExpansionTarget, operatingSystem string) (*UserResolver, error) {
+	var users []User
+	var err error
+	switch strings.ToLower(operatingSystem) {
+	case "linux", "esxi":
+		users, err = LinuxUsers(collector.FS())
+	case "darwin":
+		users, err = MacOSUsers(collector.FS())
+	case windows:
+		users, err = WindowsUsers(collector.Registry())
+	default:
+		return nil, fmt.Errorf("unsupported operating system %s", operatingSystem)
+	}
+	return &UserResolver{Users: users}, err
+}
+
+// Resolve returns the values of a users.* parameter for all users.
+func (r *UserResolver) Resolve(parameter string) ([]string, error) {
+	if !strings.HasPrefix(parameter, "users.") {
+		return nil, ErrUnknownParameter
+	}
+	attribute := strings.TrimPrefix(parameter, "users.")
+
+	known := false
+	for _, folder := range userFolders {
+		if folder.name == attribute {
+			known = true
+		}
+	}
+
+	var values []string
+	seen := map[string]bool{}
+	for _, user := range r.Users {
+		value, ok := user.attribute(attribute)
+		known = known || ok
+		if value != "" && !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	if !known {
+		return nil, ErrUnknownParameter
+	}
+	return values, nil
+}
+
+func (u User) attribute(attribute string) (string, bool) {
+	switch attribute {
+	case "username":
+		return u.Username, true
+	case "uid":
+		return u.UID, true
+	case "gid":
+		return u.GID, true
+	case "sid":
+		return u.SID, true
+	case "full_name":
+		return u.FullName, true
+	case "shell":
+		return u.Shell, true
+	case "homedir":
+		return u.Homedir, true
+	case "userprofile":
+		if u.SID == "" {
+			return "", true
+		}
+		return u.Homedir, true
+	}
+	value, ok := u.Folders[attribute]
+	return value, ok
+}
+
+// LinuxUsers reads the users of a Linux or ESXi system from /etc/passwd.
+func LinuxUsers(fsys fs.FS) ([]User, error) {
+	data, err := fs.ReadFile(fsys, "etc/passwd")
+	if err != nil {
+		return nil, err
+	}
+
+	var users []User
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) < 7 { // nolint:gomnd
+			continue
+		}
+		users = append(users, User{
+			Username: fields[0],
+			UID:      fields[2],
+			GID:      fields[3],
+			FullName: strings.Split(fields[4], ",")[0],
+			Homedir:  fields[5],
+			Shell:    fields[6],
+		})
+	}
+	return users, scanner.Err()
+}
+
+// MacOSUsers reads the users of a macOS system from the local directory
+// service and the /Users directory.
+func MacOSUsers(fsys fs.FS) ([]User, error) {
+	var users []User
+	known := map[string]bool{}
+
+	entries, err := fs.ReadDir(fsys, dslocalUsers)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".plist") {
+			continue
+		}
+		user, err := dslocalUser(fsys, path.Join(dslocalUsers, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if user.Username != "" {
+			users = append(users, user)
+			known[user.Username] = true
+		}
+	}
+
+	entries, err = fs.ReadDir(fsys, "Users")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || known[name] || name == "Shared" || strings.HasPrefix(name, ".") {
+			continue
+		}
+		users = append(users, User{Username: name, Homedir: "/Users/" + name})
+	}
+
+	if len(users) == 0 {
+		return nil, fmt.Errorf("no users found in %s or Users", dslocalUsers)
+	}
+	return users, nil
+}
+
+func dslocalUser(fsys fs.FS, name string) (User, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return User{}, err
+	}
+	plist, err := decodePlist(data)
+	if err != nil {
+		return User{}, fmt.Errorf("%s: %s", name, err)
+	}
+	dict, ok := plist.(map[string]interface{})
+	if !ok {
+		return User{}, fmt.Errorf("%s: not a dictionary", name)
+	}
+	return User{
+		Username: plistString(dict, "name"),
+		UID:      plistString(dict, "uid"),
+		GID:      plistString(dict, "gid"),
+		FullName: plistString(dict, "realname"),
+		Shell:    plistString(dict, "shell"),
+		Homedir:  plistString(dict, "home"),
+	}, nil
+}
+
+// plistString returns the first value of a dslocal attribute.
+func plistString(dict map[string]interface{}, key string) string {
+	switch value := dict[key].(type) {
+	case []interface{}:
+		if len(value) > 0 {
+			return fmt.Sprint(value[0])
+		}
+	case nil:
+	default:
+		return fmt.Sprint(value)
+	}
+	return ""
+}
+
+// WindowsUsers reads the users of a Windows system from the ProfileList and
+// the user shell folders in the registry. Folders that are not set in the
+// registry are set to their default values.
+func WindowsUsers(registry fs.FS) ([]User, error) {
+	entries, err := fs.ReadDir(registry, profileListKey)
+	if err != nil {
+		return nil, err
+	}
+
+	environment := NewEnvironResolver(registry).Environment
+
+	var users []User
+	for _, entry := range entries {
+		sid := entry.Name()
+		if !strings.HasPrefix(sid, "S-1-5-21-") {
+			continue
+		}
+		profile, err := readRegistryValue(registry, path.Join(profileListKey, sid), "ProfileImagePath")
+		if err != nil || profile == "" {
+			continue
+		}
+		profile = expandWindowsVariables(profile, environment)
+
+		user := User{
+			SID:      sid,
+			Username: profile[strings.LastIndexAny(profile, `\/`)+1:],
+			Homedir:  profile,
+			Folders:  map[string]string{},
+		}
+
+		variables := map[string]string{"USERPROFILE": profile}
+		for name, value := range environment {
+			variables[name] = value
+		}
+		for _, folder := range userFolders {
+			value := ""
+			if folder.key != "" {
+				value, _ = readRegistryValue(registry, path.Join("HKEY_USERS", sid, folder.key), folder.value)
+			}
+			if value == "" {
+				value = folder.fallback
+			}
+			value = expandWindowsVariables(value, variables)
+			user.Folders[folder.name] = value
+			if folder.variable != "" {
+				variables[folder.variable] = value
+			}
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+var windowsVariable = regexp.MustCompile(`%([^%]+)%`)
+
+// maxExpansions limits the expansion of nested variables.
+const maxExpansions = 10
+
+// expandWindowsVariables replaces %VARIABLE% by their values, including
+// variables in those values. Variable names are case insensitive and need to
+// be upper case in the variables map. Unknown variables are kept.
+func expandWindowsVariables(s string, variables map[string]string) string {
+	for i := 0; i < maxExpansions; i++ {
+		expanded := windowsVariable.ReplaceAllStringFunc(s, func(match string) string {
+			if value, ok := variables[strings.ToUpper(match[1:len(match)-1])]; ok {
+				return value
+			}
+			return match
+		})
+		if expanded == s {
+			break
+		}
+		s = expanded
+	}
+	return s
+}
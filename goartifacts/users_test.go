@@ -0,0 +1,171 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+const alicePlist = "YnBsaXN0MDDZAQIDBAUGBwgJCgwOEBIUFhgaXlNoYWRvd0hhc2hEYXRhXGdlbmVyYXRlZHVpZFNnaWRUaG9tZVRuYW1lVnBhc3N3ZFhyZWFsbmFtZVVzaGVsbFN1aWShC0IAAaENU0FCQ6EPUjIwoRFcL1VzZXJzL2FsaWNloRNVYWxpY2WhFVgqKioqKioqKqEXXUFsaWNlIExpZGRlbGyhGVgvYmluL3pzaKEbUzUwMQgbKjc7QEVMVVtfYWRmamxvcX6AhoiRk6GjrK4AAAAAAAABAQAAAAAAAAAcAAAAAAAAAAAAAAAAAAAAsg=="
+
+const rootPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>home</key>
+	<array><string>/var/root</string></array>
+	<key>name</key>
+	<array><string>root</string><string>BUILTIN\Local System</string></array>
+	<key>realname</key>
+	<array><string>System Administrator</string></array>
+	<key>shell</key>
+	<array><string>/bin/sh</string></array>
+	<key>uid</key>
+	<array><string>0</string></array>
+</dict>
+</plist>`
+
+func getLinuxFS() fstest.MapFS {
+	return fstest.MapFS{
+		"etc/passwd": &fstest.MapFile{Data: []byte("# comment\nroot:x:0:0:root:/root:/bin/bash\nalice:x:1000:1000:Alice Liddell,,,:/home/alice:/bin/zsh\n")},
+	}
+}
+
+func getMacOSFS(t *testing.T) fstest.MapFS {
+	alice, err := base64.StdEncoding.DecodeString(alicePlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fstest.MapFS{
+		dslocalUsers + "/alice.plist": &fstest.MapFile{Data: alice},
+		dslocalUsers + "/root.plist":  &fstest.MapFile{Data: []byte(rootPlist)},
+		"Users/alice/.zshrc":          &fstest.MapFile{},
+		"Users/bob/.zshrc":            &fstest.MapFile{},
+		"Users/Shared/file":           &fstest.MapFile{},
+	}
+}
+
+func getWindowsRegistry() fstest.MapFS {
+	aliceSID := "S-1-5-21-1-2-3-1001"
+	bobSID := "S-1-5-21-1-2-3-1002"
+	return fstest.MapFS{
+		profileListKey + "/S-1-5-18/ProfileImagePath":                           &fstest.MapFile{Data: []byte(`%systemroot%\system32\config\systemprofile`)},
+		profileListKey + "/" + aliceSID + "/ProfileImagePath":                   &fstest.MapFile{Data: []byte(`C:\Users\alice`)},
+		profileListKey + "/" + bobSID + "/ProfileImagePath":                     &fstest.MapFile{Data: []byte("%SystemDrive%\\Users\\bob\x00")},
+		"HKEY_USERS/" + bobSID + "/" + shellFoldersKey + "/AppData":             &fstest.MapFile{Data: []byte(`D:\Roaming\bob`)},
+		"HKEY_USERS/" + bobSID + "/" + shellFoldersKey + "/Local AppData":       &fstest.MapFile{Data: []byte(`%USERPROFILE%\Local`)},
+		"HKEY_USERS/" + bobSID + "/" + shellFoldersKey + "/Unknown Folder Name": &fstest.MapFile{Data: []byte(`x`)},
+	}
+}
+
+func TestLinuxUsers(t *testing.T) {
+	got, err := LinuxUsers(getLinuxFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []User{
+		{Username: "root", UID: "0", GID: "0", FullName: "root", Homedir: "/root", Shell: "/bin/bash"},
+		{Username: "alice", UID: "1000", GID: "1000", FullName: "Alice Liddell", Homedir: "/home/alice", Shell: "/bin/zsh"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LinuxUsers() = %#v, want %#v", got, want)
+	}
+}
+
+func TestMacOSUsers(t *testing.T) {
+	got, err := MacOSUsers(getMacOSFS(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []User{
+		{Username: "alice", UID: "501", GID: "20", FullName: "Alice Liddell", Homedir: "/Users/alice", Shell: "/bin/zsh"},
+		{Username: "root", UID: "0", FullName: "System Administrator", Homedir: "/var/root", Shell: "/bin/sh"},
+		{Username: "bob", Homedir: "/Users/bob"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MacOSUsers() = %#v, want %#v", got, want)
+	}
+}
+
+func TestWindowsUsers(t *testing.T) {
+	got, err := WindowsUsers(getWindowsRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("WindowsUsers() = %#v, want 2 users", got)
+	}
+
+	alice, bob := got[0], got[1]
+	if alice.Username != "alice" || alice.Homedir != `C:\Users\alice` {
+		t.Errorf("WindowsUsers() alice = %#v", alice)
+	}
+	if alice.Folders["appdata"] != `C:\Users\alice\AppData\Roaming` || alice.Folders["recent"] != `C:\Users\alice\AppData\Roaming\Microsoft\Windows\Recent` {
+		t.Errorf("WindowsUsers() alice folders = %#v", alice.Folders)
+	}
+	if bob.Username != "bob" || bob.Homedir != `C:\Users\bob` {
+		t.Errorf("WindowsUsers() bob = %#v", bob)
+	}
+	if bob.Folders["appdata"] != `D:\Roaming\bob` || bob.Folders["startup"] != `D:\Roaming\bob\Microsoft\Windows\Start Menu\Programs\Startup` || bob.Folders["localappdata"] != `C:\Users\bob\Local` {
+		t.Errorf("WindowsUsers() bob folders = %#v", bob.Folders)
+	}
+}
+
+func TestUserResolver_Resolve(t *testing.T) {
+	registry := getWindowsRegistry()
+	tests := []struct {
+		name            string
+		collector       ArtifactCollector
+		operatingSystem string
+		parameter       string
+		want            []string
+		wantErr         error
+	}{
+		{"linux homedir", &TestCollector{fs: getLinuxFS()}, "Linux", "users.homedir", []string{"/root", "/home/alice"}, nil},
+		{"linux sid", &TestCollector{fs: getLinuxFS()}, "Linux", "users.sid", nil, nil},
+		{"linux unknown", &TestCollector{fs: getLinuxFS()}, "Linux", "users.unknown", nil, ErrUnknownParameter},
+		{"linux other", &TestCollector{fs: getLinuxFS()}, "Linux", "environ_systemroot", nil, ErrUnknownParameter},
+		{"macos username", &TestCollector{fs: getMacOSFS(t)}, "Darwin", "users.username", []string{"alice", "root", "bob"}, nil},
+		{"windows sid", &TestCollector{fs: registry}, "Windows", "users.sid", []string{"S-1-5-21-1-2-3-1001", "S-1-5-21-1-2-3-1002"}, nil},
+		{"windows userprofile", &TestCollector{fs: registry}, "Windows", "users.userprofile", []string{`C:\Users\alice`, `C:\Users\bob`}, nil},
+		{"windows localappdata", &TestCollector{fs: registry}, "Windows", "users.localappdata", []string{`C:\Users\alice\AppData\Local`, `C:\Users\bob\Local`}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, err := NewUserResolver(tt.collector, tt.operatingSystem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Resolvers{resolver}.Resolve(tt.parameter)
+			if err != tt.wantErr {
+				t.Errorf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Volatility ranks sources by how fast their evidence changes. Lower values
+// are more volatile and should be collected first, as recommended by RFC
+// 3227.
+type Volatility int
+
+const (
+	// VolatilityLive is the state of the running system, like the output of
+	// commands and WMI queries.
+	VolatilityLive Volatility = iota
+	// VolatilityRegistry are Windows Registry keys and values.
+	VolatilityRegistry
+	// VolatilityLogs are log files that are rotated or overwritten.
+	VolatilityLogs
+	// VolatilityStatic are all other files and directories.
+	VolatilityStatic
+)
+
+var volatilityNames = []string{"live", "registry", "logs", "static"}
+
+func (v Volatility) String() string {
+	if v < 0 || int(v) >= len(volatilityNames) {
+		return fmt.Sprintf("Volatility(%d)", int(v))
+	}
+	return volatilityNames[v]
+}
+
+// ParseVolatility parses the name of a volatility class as used in the
+// volatility extension of artifact definitions: live, registry, logs or
+// static.
+func ParseVolatility(name string) (Volatility, error) {
+	for i, volatilityName := range volatilityNames {
+		if strings.EqualFold(name, volatilityName) {
+			return Volatility(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown volatility %s", name)
+}
+
+// SourceVolatility returns the volatility class of a source. The class is
+// taken from the volatility extension of the artifact definition if set and
+// inferred from the source type otherwise. Files, directories and paths of
+// artifacts labeled Logs are logs.
+func SourceVolatility(artifactDefinition ArtifactDefinition, source Source) (Volatility, error) {
+	if artifactDefinition.Extensions.Volatility != "" {
+		return ParseVolatility(artifactDefinition.Extensions.Volatility)
+	}
+	switch source.Type {
+	case SourceType.Command, SourceType.Wmi:
+		return VolatilityLive, nil
+	case SourceType.RegistryKey, SourceType.RegistryValue:
+		return VolatilityRegistry, nil
+	}
+	if containsString(artifactDefinition.Labels, "Logs") {
+		return VolatilityLogs, nil
+	}
+	return VolatilityStatic, nil
+}
+
+// ScheduledSource is a source scheduled by Schedule.
+type ScheduledSource struct {
+	Artifact   string     `json:"artifact"`
+	Index      int        `json:"index"`
+	Source     Source     `json:"source"`
+	Volatility Volatility `json:"volatility"`
+}
+
+// Schedule orders the sources of the artifact definitions by volatility, so
+// the most volatile sources can be collected first. Sources of the same class
+// keep the order of the definitions. Artifact group sources are not
+// scheduled, the definitions should be selected before, e.g. with
+// SelectArtifacts.
+func Schedule(artifactDefinitions []ArtifactDefinition) ([]ScheduledSource, error) {
+	var scheduled []ScheduledSource
+	for _, artifactDefinition := range artifactDefinitions {
+		for index, source := range artifactDefinition.Sources {
+			if source.Type == SourceType.ArtifactGroup {
+				continue
+			}
+			volatility, err := SourceVolatility(artifactDefinition, source)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", artifactDefinition.Name, err)
+			}
+			scheduled = append(scheduled, ScheduledSource{
+				Artifact:   artifactDefinition.Name,
+				Index:      index,
+				Source:     source,
+				Volatility: volatility,
+			})
+		}
+	}
+	sort.Stable(byVolatility(scheduled))
+	return scheduled, nil
+}
+
+type byVolatility []ScheduledSource
+
+func (s byVolatility) Len() int           { return len(s) }
+func (s byVolatility) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byVolatility) Less(i, j int) bool { return s[i].Volatility < s[j].Volatility }
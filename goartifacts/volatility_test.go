@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchedule(t *testing.T) {
+	source := func(sourceType string) Source { return Source{Type: sourceType} }
+	definitions := []ArtifactDefinition{
+		{Name: "Files", Sources: []Source{source(SourceType.File), source(SourceType.Directory)}},
+		{Name: "EventLogs", Labels: []string{"Logs"}, Sources: []Source{source(SourceType.File)}},
+		{Name: "Group", Sources: []Source{{Type: SourceType.ArtifactGroup, Attributes: Attributes{Names: []string{"Files"}}}}},
+		{Name: "Run", Sources: []Source{source(SourceType.RegistryKey), source(SourceType.Command)}},
+		{Name: "Processes", Sources: []Source{source(SourceType.Wmi)}},
+		{Name: "Pagefile", Extensions: Extensions{Volatility: "Live"}, Sources: []Source{source(SourceType.File)}},
+		{Name: "Values", Sources: []Source{source(SourceType.RegistryValue)}},
+	}
+
+	scheduled, err := Schedule(definitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type entry struct {
+		artifact   string
+		index      int
+		volatility Volatility
+	}
+	var got []entry
+	for _, s := range scheduled {
+		got = append(got, entry{s.Artifact, s.Index, s.Volatility})
+	}
+	want := []entry{
+		{"Run", 1, VolatilityLive},
+		{"Processes", 0, VolatilityLive},
+		{"Pagefile", 0, VolatilityLive},
+		{"Run", 0, VolatilityRegistry},
+		{"Values", 0, VolatilityRegistry},
+		{"EventLogs", 0, VolatilityLogs},
+		{"Files", 0, VolatilityStatic},
+		{"Files", 1, VolatilityStatic},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Schedule() = %v, want %v", got, want)
+	}
+
+	_, err = Schedule([]ArtifactDefinition{{Name: "Bad", Extensions: Extensions{Volatility: "fast"}, Sources: []Source{source(SourceType.File)}}})
+	if err == nil {
+		t.Error("Schedule() expected error for unknown volatility")
+	}
+}
+
+func TestVolatilityString(t *testing.T) {
+	for _, v := range []Volatility{VolatilityLive, VolatilityRegistry, VolatilityLogs, VolatilityStatic} {
+		parsed, err := ParseVolatility(v.String())
+		if err != nil || parsed != v {
+			t.Errorf("ParseVolatility(%s) = %v, %v", v, parsed, err)
+		}
+	}
+	if got := Volatility(7).String(); got != "Volatility(7)" {
+		t.Errorf("String() = %s", got)
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// volumeMarkers are entries that are usually found in the root of a volume.
+var volumeMarkers = []string{
+	"$mft", "$recycle.bin", "boot", "bootmgr", "documents and settings", "efi",
+	"pagefile.sys", "program files", "programdata", "recovery",
+	"system volume information", "users", "windows",
+}
+
+// A Volume is a partition or drive in a file system.
+type Volume struct {
+	// Prefix is the path of the volume in the file system, e.g. C or
+	// partition2. The prefix "." means the file system is the volume.
+	Prefix string
+	// Drive is the drive letter of the volume, if known.
+	Drive string
+	// System is true if the volume contains the Windows system directory.
+	System bool
+}
+
+// VolumeLayout describes the volumes of a file system.
+type VolumeLayout struct {
+	Volumes []Volume
+}
+
+// DriveMapper can be implemented by an ArtifactCollector to map drive letters
+// to paths in its file system, e.g. for mounted images with partition
+// directories.
+type DriveMapper interface {
+	Drives() map[string]string
+}
+
+// DiscoverVolumes inspects the root of a file system for volumes. It supports
+// systemfs-style layouts with drive letters like C and D, and mounted images
+// with one directory per partition. If the file system itself is a volume, a
+// single volume with the prefix "." is returned. systemDrive is used as
+// drive letter of the Windows system volume if it has none, usually C.
+func DiscoverVolumes(fsys fs.FS, systemDrive string) (*VolumeLayout, error) {
+	systemDrive = strings.ToUpper(strings.TrimSuffix(systemDrive, ":"))
+
+	if isVolume(fsys, ".") && !hasDriveDirectories(fsys) {
+		volume := Volume{Prefix: ".", System: isSystemVolume(fsys, ".")}
+		if volume.System {
+			volume.Drive = systemDrive
+		}
+		return &VolumeLayout{Volumes: []Volume{volume}}, nil
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	layout := &VolumeLayout{}
+	drives := map[string]bool{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !isDirectory(fsys, entry) {
+			continue
+		}
+		volume := Volume{Prefix: name, System: isSystemVolume(fsys, name)}
+		switch {
+		case len(name) == 1 && isLetter(name[0]):
+			volume.Drive = strings.ToUpper(name)
+		case isVolume(fsys, name):
+		default:
+			continue
+		}
+		if volume.Drive != "" {
+			drives[volume.Drive] = true
+		}
+		layout.Volumes = append(layout.Volumes, volume)
+	}
+
+	// a system volume without drive letter gets the system drive letter
+	for i, volume := range layout.Volumes {
+		if volume.System && volume.Drive == "" && !drives[systemDrive] {
+			layout.Volumes[i].Drive = systemDrive
+			drives[systemDrive] = true
+		}
+	}
+
+	sort.Stable(byVolumeOrder(layout.Volumes))
+	return layout, nil
+}
+
+// Prefixes returns the prefixes of all volumes, the system volume first, as
+// needed by ArtifactCollector.Prefixes. If the file system is a volume
+// itself, no prefixes are returned.
+func (l *VolumeLayout) Prefixes() []string {
+	var prefixes []string
+	for _, volume := range l.Volumes {
+		if volume.Prefix != "." {
+			prefixes = append(prefixes, volume.Prefix)
+		}
+	}
+	return prefixes
+}
+
+// Drives returns a mapping from drive letters to volume prefixes, as needed
+// by DriveMapper.
+func (l *VolumeLayout) Drives() map[string]string {
+	drives := map[string]string{}
+	for _, volume := range l.Volumes {
+		if volume.Drive != "" {
+			drives[volume.Drive] = volume.Prefix
+		}
+	}
+	return drives
+}
+
+// SystemVolume returns the volume that contains the Windows system
+// directory.
+func (l *VolumeLayout) SystemVolume() (Volume, bool) {
+	for _, volume := range l.Volumes {
+		if volume.System {
+			return volume, true
+		}
+	}
+	return Volume{}, false
+}
+
+// byVolumeOrder sorts the system volume first, then volumes with drive
+// letters by letter and all other volumes by name.
+type byVolumeOrder []Volume
+
+func (v byVolumeOrder) Len() int      { return len(v) }
+func (v byVolumeOrder) Swap(i, j int) { v[i], v[j] = v[j], v[i] }
+func (v byVolumeOrder) Less(i, j int) bool {
+	switch {
+	case v[i].System != v[j].System:
+		return v[i].System
+	case (v[i].Drive == "") != (v[j].Drive == ""):
+		return v[i].Drive != ""
+	case v[i].Drive != v[j].Drive:
+		return v[i].Drive < v[j].Drive
+	}
+	return v[i].Prefix < v[j].Prefix
+}
+
+func isDirectory(fsys fs.FS, entry fs.DirEntry) bool {
+	if entry.IsDir() {
+		return true
+	}
+	info, err := fs.Stat(fsys, entry.Name())
+	return err == nil && info.IsDir()
+}
+
+// hasDriveDirectories checks for systemfs-style drive letter directories.
+func hasDriveDirectories(fsys fs.FS) bool {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if len(name) == 1 && isLetter(name[0]) && isDirectory(fsys, entry) && isVolume(fsys, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isVolume(fsys fs.FS, dir string) bool {
+	for _, marker := range volumeMarkers {
+		if _, ok := findEntry(fsys, dir, marker); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isSystemVolume(fsys fs.FS, dir string) bool {
+	windowsDir, ok := findEntry(fsys, dir, "windows")
+	if !ok {
+		return false
+	}
+	_, ok = findEntry(fsys, path.Join(dir, windowsDir), "system32")
+	return ok
+}
+
+// findEntry looks up a name in a directory case insensitive.
+func findEntry(fsys fs.FS, dir, name string) (string, bool) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name(), name) {
+			return entry.Name(), true
+		}
+	}
+	return "", false
+}
+
+// mapDrive replaces a leading drive letter in a file system path by the
+// prefix of its volume.
+func mapDrive(name string, drives map[string]string) string {
+	letter := name
+	if i := strings.Index(name, "/"); i >= 0 {
+		letter = name[:i]
+	}
+	if len(letter) != 1 || !isLetter(letter[0]) {
+		return name
+	}
+	prefix, ok := drives[strings.ToUpper(letter)]
+	if !ok {
+		return name
+	}
+	if prefix == "." {
+		name = strings.TrimPrefix(name[1:], "/")
+		if name == "" {
+			return "."
+		}
+		return name
+	}
+	return prefix + name[1:]
+}
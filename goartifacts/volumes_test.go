@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package goartifacts
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDiscoverVolumes(t *testing.T) {
+	tests := []struct {
+		name         string
+		fsys         fstest.MapFS
+		systemDrive  string
+		want         []Volume
+		wantPrefixes []string
+		wantDrives   map[string]string
+	}{
+		{
+			"systemfs",
+			fstest.MapFS{"D/data/x": {}, "C/Windows/System32/cmd.exe": {}, "C/pagefile.sys": {}, "E/Users/x": {}},
+			"C:",
+			[]Volume{{Prefix: "C", Drive: "C", System: true}, {Prefix: "D", Drive: "D"}, {Prefix: "E", Drive: "E"}},
+			[]string{"C", "D", "E"},
+			map[string]string{"C": "C", "D": "D", "E": "E"},
+		},
+		{
+			"mounted image",
+			fstest.MapFS{"p1/EFI/Boot/bootx64.efi": {}, "p3/WINDOWS/system32/cmd.exe": {}, "p2/$MFT": {}, "other/file": {}, "file": {}},
+			"",
+			[]Volume{{Prefix: "p3", Drive: "", System: true}, {Prefix: "p1"}, {Prefix: "p2"}},
+			[]string{"p3", "p1", "p2"},
+			map[string]string{},
+		},
+		{
+			"mounted image with system drive",
+			fstest.MapFS{"p1/EFI/Boot/bootx64.efi": {}, "p2/Windows/System32/cmd.exe": {}},
+			"d:",
+			[]Volume{{Prefix: "p2", Drive: "D", System: true}, {Prefix: "p1"}},
+			[]string{"p2", "p1"},
+			map[string]string{"D": "p2"},
+		},
+		{
+			"volume",
+			fstest.MapFS{"Windows/System32/cmd.exe": {}, "Users/x": {}},
+			"C",
+			[]Volume{{Prefix: ".", Drive: "C", System: true}},
+			nil,
+			map[string]string{"C": "."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiscoverVolumes(tt.fsys, tt.systemDrive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Volumes, tt.want) {
+				t.Errorf("DiscoverVolumes() = %v, want %v", got.Volumes, tt.want)
+			}
+			if !reflect.DeepEqual(got.Prefixes(), tt.wantPrefixes) {
+				t.Errorf("Prefixes() = %v, want %v", got.Prefixes(), tt.wantPrefixes)
+			}
+			if !reflect.DeepEqual(got.Drives(), tt.wantDrives) {
+				t.Errorf("Drives() = %v, want %v", got.Drives(), tt.wantDrives)
+			}
+		})
+	}
+}
+
+func Test_mapDrive(t *testing.T) {
+	drives := map[string]string{"C": "p2", "D": "."}
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"mapped", "C/Windows", "p2/Windows"},
+		{"lower case", "c/Windows", "p2/Windows"},
+		{"drive only", "C", "p2"},
+		{"root volume", "D/Windows", "Windows"},
+		{"root volume drive only", "D", "."},
+		{"unknown drive", "E/Windows", "E/Windows"},
+		{"no drive", "Windows/System32", "Windows/System32"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapDrive(tt.in, drives); got != tt.want {
+				t.Errorf("mapDrive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
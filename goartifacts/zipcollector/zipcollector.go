@@ -0,0 +1,289 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+// Package zipcollector implements a reference goartifacts.ArtifactCollectorV2
+// that copies collected files into a ZIP archive. The archive contains the
+// files under their original paths and a manifest with hashes, timestamps
+// and sizes of all collected items.
+package zipcollector
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/forensicanalysis/artifactlib/goartifacts"
+)
+
+// ManifestName is the name of the manifest in the archive.
+const ManifestName = "manifest.json"
+
+// FilesDir is the directory in the archive that contains the collected files.
+const FilesDir = "files"
+
+// Manifest describes the content of the archive.
+type Manifest struct {
+	Start   time.Time            `json:"start"`
+	End     time.Time            `json:"end"`
+	Records []goartifacts.Record `json:"records"`
+	// Errors lists failed collections prefixed by the artifact name and
+	// incomplete files in the archive prefixed by their export path.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Options configure a Collector.
+type Options struct {
+	// Registry is the registry file system returned by Registry. The
+	// Collector does not collect registry sources, this only affects the
+	// expansion of parameters.
+	Registry fs.FS
+	// Prefixes are passed to the expansion, see ExpansionTarget.
+	Prefixes []string
+	// Resolver resolves parameters, all parameters are unknown if it is nil.
+	Resolver goartifacts.ParameterResolver
+}
+
+// Collector collects artifacts from a file system into a ZIP archive.
+type Collector struct {
+	fsys     fs.FS
+	options  Options
+	zip      *zip.Writer
+	manifest Manifest
+	written  map[string]*goartifacts.FileResult
+	closed   bool
+}
+
+// New creates a Collector that reads from fsys and writes the archive to w.
+// Close must be called to write the manifest and finish the archive.
+func New(w io.Writer, fsys fs.FS, options Options) *Collector {
+	return &Collector{
+		fsys:     fsys,
+		options:  options,
+		zip:      zip.NewWriter(w),
+		manifest: Manifest{Start: time.Now().UTC()},
+		written:  map[string]*goartifacts.FileResult{},
+	}
+}
+
+// Resolve resolves a parameter with the configured resolver.
+func (c *Collector) Resolve(parameter string) ([]string, error) {
+	if c.options.Resolver == nil {
+		return nil, goartifacts.ErrUnknownParameter
+	}
+	return c.options.Resolver.Resolve(parameter)
+}
+
+// FS returns the file system that is collected.
+func (c *Collector) FS() fs.FS {
+	return c.fsys
+}
+
+// Registry returns the registry file system.
+func (c *Collector) Registry() fs.FS {
+	return c.options.Registry
+}
+
+// Prefixes returns the configured prefixes.
+func (c *Collector) Prefixes() []string {
+	return c.options.Prefixes
+}
+
+// Collect expands the source and adds the results to the archive. It
+// returns the first error, which is also recorded in the manifest. The
+// source index of the records is taken from the context, see
+// goartifacts.SourceIndex, and is -1 if Collect is not called by
+// goartifacts.RunContext.
+func (c *Collector) Collect(ctx context.Context, name string, source goartifacts.Source) (goartifacts.Result, error) {
+	result, err := c.collect(ctx, name, source)
+	if err != nil {
+		c.manifest.Errors = append(c.manifest.Errors, fmt.Sprintf("%s: %s", name, err))
+	}
+	return result, err
+}
+
+func (c *Collector) collect(ctx context.Context, name string, source goartifacts.Source) (goartifacts.Result, error) {
+	if c.closed {
+		return goartifacts.Result{}, fmt.Errorf("collector is closed")
+	}
+	if err := ctx.Err(); err != nil {
+		return goartifacts.Result{}, err
+	}
+
+	index, ok := goartifacts.SourceIndex(ctx)
+	if !ok {
+		index = -1
+	}
+
+	var collectItem func(string) (interface{}, error)
+	switch source.Type {
+	case goartifacts.SourceType.File:
+		collectItem = c.collectFile
+	case goartifacts.SourceType.Directory:
+		collectItem = c.collectDirectory
+	case goartifacts.SourceType.Path:
+		collectItem = c.collectPath
+	default:
+		return goartifacts.Result{}, fmt.Errorf("unsupported source type %s", source.Type)
+	}
+
+	var result goartifacts.Result
+	var firstErr error
+	for _, p := range goartifacts.ExpandSource(source, c).Attributes.Paths {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		data, err := collectItem(p)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		record, err := goartifacts.NewRecord(name, index, p, data)
+		if err != nil {
+			return result, err
+		}
+		result.Items = append(result.Items, p)
+		result.Records = append(result.Records, record)
+		c.manifest.Records = append(c.manifest.Records, record)
+	}
+	return result, firstErr
+}
+
+// Manifest returns the manifest of the items collected so far.
+func (c *Collector) Manifest() Manifest {
+	return c.manifest
+}
+
+// Close writes the manifest and finishes the archive. It does not close the
+// underlying writer.
+func (c *Collector) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.manifest.End = time.Now().UTC()
+
+	w, err := c.zip.CreateHeader(&zip.FileHeader{Name: ManifestName, Method: zip.Deflate, Modified: c.manifest.End})
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c.manifest); err != nil {
+		return err
+	}
+	return c.zip.Close()
+}
+
+func (c *Collector) collectFile(name string) (interface{}, error) {
+	fsPath := fsPath(name)
+	if result, ok := c.written[fsPath]; ok {
+		return result, nil
+	}
+
+	f, err := c.fsys.Open(fsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", name)
+	}
+
+	export := path.Join(FilesDir, fsPath)
+	header := &zip.FileHeader{Name: export, Method: zip.Deflate, Modified: info.ModTime()}
+	w, err := c.zip.CreateHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	size, hashes, err := goartifacts.HashCopy(w, f)
+	if err != nil {
+		// the archive contains the truncated file without a record
+		c.manifest.Errors = append(c.manifest.Errors, fmt.Sprintf("%s: incomplete: %s", export, err))
+		return nil, err
+	}
+
+	result := &goartifacts.FileResult{
+		Path:     name,
+		Size:     size,
+		Modified: info.ModTime().UTC(),
+		Hashes:   hashes,
+		Export:   export,
+	}
+	c.written[fsPath] = result
+	return result, nil
+}
+
+func (c *Collector) collectDirectory(name string) (interface{}, error) {
+	entries, err := fs.ReadDir(c.fsys, fsPath(name))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &goartifacts.DirectoryResult{Path: name, Entries: []goartifacts.DirectoryEntry{}}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		result.Entries = append(result.Entries, goartifacts.DirectoryEntry{
+			Name:     entry.Name(),
+			Size:     info.Size(),
+			Mode:     info.Mode().String(),
+			Modified: info.ModTime().UTC(),
+			IsDir:    entry.IsDir(),
+		})
+	}
+	return result, nil
+}
+
+func (c *Collector) collectPath(name string) (interface{}, error) {
+	info, err := fs.Stat(c.fsys, fsPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &goartifacts.PathResult{Path: name}, nil
+		}
+		return nil, err
+	}
+	return &goartifacts.PathResult{Path: name, Exists: true, IsDir: info.IsDir(), Modified: info.ModTime().UTC()}, nil
+}
+
+func fsPath(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2020 Siemens AG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+//
+// Author(s): Jonas Plum
+
+package zipcollector
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/forensicanalysis/artifactlib/goartifacts"
+)
+
+func TestCollector(t *testing.T) {
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"etc/passwd":         &fstest.MapFile{Data: []byte("foo"), ModTime: modified},
+		"var/log/syslog":     &fstest.MapFile{Data: []byte("bar")},
+		"var/log/auth.log":   &fstest.MapFile{Data: []byte("baz")},
+		"var/log/apt/a.log":  &fstest.MapFile{Data: []byte("a")},
+		"home/user/.profile": &fstest.MapFile{Data: []byte("")},
+	}
+	definitions := []goartifacts.ArtifactDefinition{
+		{Name: "Passwd", Sources: []goartifacts.Source{{Type: goartifacts.SourceType.File, Attributes: goartifacts.Attributes{Paths: []string{"/etc/passwd"}}}}},
+		{Name: "Logs", Sources: []goartifacts.Source{
+			{Type: goartifacts.SourceType.File, Attributes: goartifacts.Attributes{Paths: []string{"/etc/passwd"}}, SupportedOs: []string{"Windows"}},
+			{Type: goartifacts.SourceType.File, Attributes: goartifacts.Attributes{Paths: []string{"/var/log/*.log", "/var/log/syslog", "/etc/passwd"}}},
+			{Type: goartifacts.SourceType.Directory, Attributes: goartifacts.Attributes{Paths: []string{"/var/log"}}},
+		}},
+		{Name: "Home", Sources: []goartifacts.Source{{Type: goartifacts.SourceType.Path, Attributes: goartifacts.Attributes{Paths: []string{"/home/*"}}}}},
+		{Name: "Registry", Sources: []goartifacts.Source{{Type: goartifacts.SourceType.RegistryKey, Attributes: goartifacts.Attributes{Keys: []string{`HKEY_LOCAL_MACHINE\SOFTWARE`}}}}},
+	}
+
+	buf := &bytes.Buffer{}
+	collector := New(buf, fsys, Options{})
+	report, err := goartifacts.RunContext(context.Background(), definitions, []string{"Passwd", "Logs", "Home", "Registry"}, collector, goartifacts.RunOptions{OS: "Linux"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Name != "Registry" {
+		t.Errorf("failed = %v, want Registry", failed)
+	}
+	if err := collector.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	var manifest Manifest
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name == ManifestName {
+			if err := json.Unmarshal(b, &manifest); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if _, ok := files[f.Name]; ok {
+			t.Errorf("duplicate file %s", f.Name)
+		}
+		files[f.Name] = string(b)
+		if f.Name == "files/etc/passwd" && !f.Modified.Equal(modified) {
+			t.Errorf("modified = %s, want %s", f.Modified, modified)
+		}
+	}
+
+	wantFiles := map[string]string{
+		"files/etc/passwd":       "foo",
+		"files/var/log/syslog":   "bar",
+		"files/var/log/auth.log": "baz",
+	}
+	if len(files) != len(wantFiles) {
+		t.Errorf("files = %v, want %v", files, wantFiles)
+	}
+	for name, content := range wantFiles {
+		if files[name] != content {
+			t.Errorf("file %s = %q, want %q", name, files[name], content)
+		}
+	}
+
+	if len(manifest.Records) != 6 {
+		t.Fatalf("records = %d, want 6", len(manifest.Records))
+	}
+	passwd := manifest.Records[0]
+	file, ok := passwd.Data.(*goartifacts.FileResult)
+	if !ok || passwd.Artifact != "Passwd" || passwd.Type != goartifacts.SourceType.File {
+		t.Fatalf("unexpected record %#v", passwd)
+	}
+	wantHashes := goartifacts.Hashes{
+		MD5:    "acbd18db4cc2f85cedef654fccc4a4d8",
+		SHA1:   "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33",
+		SHA256: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+	}
+	if file.Hashes != wantHashes || file.Size != 3 || !file.Modified.Equal(modified) || file.Export != "files/etc/passwd" {
+		t.Errorf("unexpected file result %#v", file)
+	}
+
+	directory, ok := manifest.Records[4].Data.(*goartifacts.DirectoryResult)
+	if !ok || manifest.Records[4].SourceIndex != 2 || len(directory.Entries) != 3 {
+		t.Errorf("unexpected directory record %#v", manifest.Records[4])
+	}
+	path, ok := manifest.Records[5].Data.(*goartifacts.PathResult)
+	if !ok || !path.Exists || !path.IsDir {
+		t.Errorf("unexpected path record %#v", manifest.Records[5])
+	}
+
+	if len(manifest.Errors) != 1 {
+		t.Errorf("errors = %v, want unsupported registry key", manifest.Errors)
+	}
+	for i, want := range []int{0, 1, 1, 1, 2, 0} {
+		if manifest.Records[i].SourceIndex != want {
+			t.Errorf("record %d source index = %d, want %d", i, manifest.Records[i].SourceIndex, want)
+		}
+	}
+}
+
+func TestCollectorWithoutIndex(t *testing.T) {
+	collector := New(&bytes.Buffer{}, fstest.MapFS{"foo": &fstest.MapFile{}}, Options{})
+	source := goartifacts.Source{Type: goartifacts.SourceType.Path, Attributes: goartifacts.Attributes{Paths: []string{"/foo"}}}
+	result, err := collector.Collect(context.Background(), "Foo", source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Records) != 1 || result.Records[0].SourceIndex != -1 {
+		t.Errorf("records = %#v, want source index -1", result.Records)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := collector.Collect(ctx, "Foo", source); err != context.Canceled {
+		t.Errorf("Collect() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+type failingFS struct {
+	fstest.MapFS
+}
+
+func (f failingFS) Open(name string) (fs.File, error) {
+	file, err := f.MapFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return failingFile{file}, nil
+}
+
+type failingFile struct {
+	fs.File
+}
+
+func (failingFile) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestCollectorReadError(t *testing.T) {
+	buf := &bytes.Buffer{}
+	collector := New(buf, failingFS{fstest.MapFS{"foo": &fstest.MapFile{Data: []byte("foo")}}}, Options{})
+	source := goartifacts.Source{Type: goartifacts.SourceType.File, Attributes: goartifacts.Attributes{Paths: []string{"/foo"}}}
+	if _, err := collector.Collect(context.Background(), "Foo", source); err == nil {
+		t.Fatal("Collect() expected error")
+	}
+	if err := collector.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.File) != 2 || r.File[0].Name != "files/foo" {
+		t.Errorf("archive = %v, want files/foo and manifest", r.File)
+	}
+
+	manifest := collector.Manifest()
+	if len(manifest.Records) != 0 {
+		t.Errorf("records = %#v, want none", manifest.Records)
+	}
+	want := []string{"files/foo: incomplete: read failed", "Foo: read failed"}
+	if !reflect.DeepEqual(manifest.Errors, want) {
+		t.Errorf("errors = %v, want %v", manifest.Errors, want)
+	}
+}